@@ -0,0 +1,180 @@
+package consoleEx
+
+import (
+	"io"
+	"sync"
+)
+
+// DropPolicy is what an AsyncWriter does with an event when its queue is
+// full.
+type DropPolicy int
+
+const (
+	// DropNewest discards the event being written.
+	DropNewest DropPolicy = iota
+	// DropOldest discards the oldest queued event to make room.
+	DropOldest
+	// Block waits for room, slowing the producer down to the sink.
+	Block
+)
+
+// AsyncWriter queues events in a ring buffer and writes them to Next from
+// a background goroutine, so a slow terminal or network file system does
+// not stall the code logging. When the queue is full Policy decides what
+// gives; OnDrop, if set, is called from the background goroutine with the
+// total dropped so far after events were dropped. Fatal and panic events
+// are queued even when full and wait for everything before them to be
+// written.
+type AsyncWriter struct {
+	Next   io.Writer
+	Policy DropPolicy
+	OnDrop func(dropped uint64)
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	ring     [][]byte
+	head     int
+	n        int
+	writing  bool
+	closed   bool
+	dropped  uint64
+	reported uint64
+	done     chan struct{}
+}
+
+// NewAsyncWriter returns an AsyncWriter queueing up to size events, 1024
+// if size is not positive.
+func NewAsyncWriter(next io.Writer, size int, policy DropPolicy) *AsyncWriter {
+	if size <= 0 {
+		size = 1024
+	}
+	w := &AsyncWriter{Next: next, Policy: policy, ring: make([][]byte, size), done: make(chan struct{})}
+	w.cond = sync.NewCond(&w.mu)
+	go w.loop()
+	return w
+}
+
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	event := append([]byte(nil), p...)
+	priority := IsPriority(p)
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return 0, errSinkClosed
+	}
+	for w.n == len(w.ring) && (w.Policy == Block || priority) && !w.closed {
+		w.cond.Wait()
+	}
+	switch {
+	case w.closed:
+		w.mu.Unlock()
+		return 0, errSinkClosed
+	case w.n < len(w.ring):
+		w.push(event)
+	case w.Policy == DropOldest:
+		w.ring[w.head] = nil
+		w.head = (w.head + 1) % len(w.ring)
+		w.n--
+		w.dropped++
+		w.push(event)
+	default:
+		w.dropped++
+	}
+	w.cond.Broadcast()
+	w.mu.Unlock()
+	if priority {
+		w.Flush()
+	}
+	return len(p), nil
+}
+
+func (w *AsyncWriter) push(event []byte) {
+	w.ring[(w.head+w.n)%len(w.ring)] = event
+	w.n++
+}
+
+func (w *AsyncWriter) loop() {
+	defer close(w.done)
+	var batch [][]byte
+	for {
+		w.mu.Lock()
+		for w.n == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if w.n == 0 {
+			w.mu.Unlock()
+			return
+		}
+		batch = batch[:0]
+		for ; w.n > 0; w.n-- {
+			batch = append(batch, w.ring[w.head])
+			w.ring[w.head] = nil
+			w.head = (w.head + 1) % len(w.ring)
+		}
+		w.writing = true
+		w.cond.Broadcast()
+		w.mu.Unlock()
+		for _, event := range batch {
+			w.Next.Write(event)
+		}
+		w.mu.Lock()
+		w.writing = false
+		dropped := w.dropped
+		report := dropped != w.reported && w.OnDrop != nil
+		w.reported = dropped
+		w.cond.Broadcast()
+		w.mu.Unlock()
+		if report {
+			w.OnDrop(dropped)
+		}
+	}
+}
+
+// Flush waits until the queued events are written, then flushes Next.
+func (w *AsyncWriter) Flush() error {
+	w.mu.Lock()
+	for (w.n > 0 || w.writing) && !w.closed {
+		w.cond.Wait()
+	}
+	w.mu.Unlock()
+	if f, ok := w.Next.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Close writes the queued events and stops the background goroutine. Next
+// is not closed.
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+	<-w.done
+	if f, ok := w.Next.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Dropped returns the number of events dropped so far.
+func (w *AsyncWriter) Dropped() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.dropped
+}
+
+// Pressure reports the queued events against the queue size.
+func (w *AsyncWriter) Pressure() Pressure {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return newPressure(w.n, len(w.ring), w.dropped)
+}
+
+// Async returns a Decorator queueing events for a background goroutine,
+// see AsyncWriter.
+func Async(size int, policy DropPolicy) Decorator {
+	return func(next io.Writer) io.Writer {
+		return NewAsyncWriter(next, size, policy)
+	}
+}
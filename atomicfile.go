@@ -0,0 +1,82 @@
+package consoleEx
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// AtomicFile collects a batch job's log in a hidden temporary file next to
+// its final path, so collectors watching the directory never see a half
+// written log. Commit moves it into place on success, Fail moves it to
+// "failed-<name>" instead. Closing without either counts as a failure.
+type AtomicFile struct {
+	mu   sync.Mutex
+	f    *os.File
+	path string
+}
+
+// CreateAtomicFile starts a log that will end up at path.
+func CreateAtomicFile(path string) (*AtomicFile, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	f, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	return &AtomicFile{f: f, path: path}, nil
+}
+
+func (a *AtomicFile) Write(p []byte) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.f == nil {
+		return 0, errSinkClosed
+	}
+	return a.f.Write(p)
+}
+
+// Commit syncs the log and renames it to its final path.
+func (a *AtomicFile) Commit() error {
+	return a.finish(a.path)
+}
+
+// Fail renames the log to "failed-<name>" in the same directory.
+func (a *AtomicFile) Fail() error {
+	dir, base := filepath.Split(a.path)
+	return a.finish(filepath.Join(dir, "failed-"+base))
+}
+
+// Finish commits if err is nil and fails otherwise, for use as
+//
+//	defer func() { log.Finish(err) }()
+func (a *AtomicFile) Finish(err error) error {
+	if err != nil {
+		return a.Fail()
+	}
+	return a.Commit()
+}
+
+func (a *AtomicFile) Close() error {
+	return a.Fail()
+}
+
+func (a *AtomicFile) finish(target string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.f == nil {
+		return nil
+	}
+	f := a.f
+	a.f = nil
+	err := f.Sync()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), target)
+}
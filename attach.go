@@ -0,0 +1,133 @@
+package consoleEx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Attachment is a binary blob, such as a heap profile or a request body,
+// logged as a field. It travels inline, base64 encoded, until an
+// AttachmentWriter moves it to disk:
+//
+//	log.Error().Interface("body", consoleEx.Attach(body, "application/json")).Msg("bad request")
+type Attachment struct {
+	Data []byte `json:"$attach"`
+	Type string `json:"type,omitempty"`
+}
+
+// Attach returns data as an Attachment of the given MIME type.
+func Attach(data []byte, contentType string) Attachment {
+	return Attachment{Data: data, Type: contentType}
+}
+
+// AttachmentRef replaces an Attachment in events leaving an
+// AttachmentWriter.
+type AttachmentRef struct {
+	Ref  string `json:"ref"`
+	Size int    `json:"size"`
+	Type string `json:"type,omitempty"`
+}
+
+var attachPrefix = []byte(`{"$attach":`)
+
+// AttachmentWriter stores the attachments of events in Dir, named by the
+// SHA-256 of their content so identical blobs are kept once, and passes
+// the events on to Next with a "sha256:<hex>" AttachmentRef in their
+// place. A blob that cannot be stored is left inline.
+type AttachmentWriter struct {
+	Next io.Writer
+	Dir  string
+}
+
+func (w AttachmentWriter) Write(p []byte) (int, error) {
+	if !bytes.Contains(p, attachPrefix) {
+		return w.Next.Write(p)
+	}
+	if _, err := w.Next.Write(w.extract(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w AttachmentWriter) extract(p []byte) []byte {
+	out := make([]byte, 0, len(p))
+	for {
+		i := bytes.Index(p, attachPrefix)
+		if i < 0 {
+			return append(out, p...)
+		}
+		d := json.NewDecoder(bytes.NewReader(p[i:]))
+		var a Attachment
+		if err := d.Decode(&a); err != nil {
+			out = append(out, p[:i+len(attachPrefix)]...)
+			p = p[i+len(attachPrefix):]
+			continue
+		}
+		end := i + int(d.InputOffset())
+		ref, err := w.store(a)
+		if err != nil {
+			out = append(out, p[:end]...)
+		} else {
+			b, _ := json.Marshal(ref)
+			out = append(append(out, p[:i]...), b...)
+		}
+		p = p[end:]
+	}
+}
+
+// store writes the blob unless it is already there.
+func (w AttachmentWriter) store(a Attachment) (AttachmentRef, error) {
+	sum := sha256.Sum256(a.Data)
+	name := hex.EncodeToString(sum[:])
+	ref := AttachmentRef{Ref: "sha256:" + name, Size: len(a.Data), Type: a.Type}
+	path := filepath.Join(w.Dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return ref, nil
+	}
+	if err := os.MkdirAll(w.Dir, 0755); err != nil {
+		return ref, err
+	}
+	f, err := os.CreateTemp(w.Dir, "."+name+"-*.tmp")
+	if err != nil {
+		return ref, err
+	}
+	_, err = f.Write(a.Data)
+	if err == nil {
+		err = f.Chmod(0644)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return ref, err
+}
+
+// OpenAttachment opens the blob an AttachmentRef from dir points to.
+func OpenAttachment(dir, ref string) (*os.File, error) {
+	const prefix = "sha256:"
+	if len(ref) != len(prefix)+64 || ref[:len(prefix)] != prefix {
+		return nil, os.ErrNotExist
+	}
+	if _, err := hex.DecodeString(ref[len(prefix):]); err != nil {
+		return nil, os.ErrNotExist
+	}
+	return os.Open(filepath.Join(dir, ref[len(prefix):]))
+}
+
+// Attachments returns a Decorator storing attachments in dir, see
+// AttachmentWriter.
+func Attachments(dir string) Decorator {
+	return func(next io.Writer) io.Writer {
+		return AttachmentWriter{Next: next, Dir: dir}
+	}
+}
@@ -0,0 +1,194 @@
+package consoleEx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+// AuditFieldName marks audit events, set to true.
+var AuditFieldName = "audit"
+
+// Outcomes commonly used in AuditEvent.Outcome.
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+	AuditDenied  = "denied"
+)
+
+var auditFields = []string{"actor", "action", "resource", "outcome"}
+
+// AuditEvent records who did what to which resource and how it ended.
+// Actor, Action, Resource and Outcome are required.
+type AuditEvent struct {
+	Actor    string
+	Action   string
+	Resource string
+	Outcome  string
+	Reason   string
+	// Fields holds additional context such as the source address.
+	Fields map[string]interface{}
+}
+
+// Validate reports the required fields that are missing.
+func (a AuditEvent) Validate() error {
+	var missing []string
+	for i, v := range []string{a.Actor, a.Action, a.Resource, a.Outcome} {
+		if strings.TrimSpace(v) == "" {
+			missing = append(missing, auditFields[i])
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("consoleEx: audit event missing %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// MarshalJSON renders the event as an info level log line.
+func (a AuditEvent) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(a.Fields)+8)
+	for k, v := range a.Fields {
+		m[k] = v
+	}
+	m[LevelFieldName] = InfoLevel.String()
+	m[TimestampFieldName] = time.Now().Format(TimeFieldFormat)
+	m[MessageFieldName] = a.Action + " " + a.Resource
+	m[AuditFieldName] = true
+	m["actor"], m["action"], m["resource"], m["outcome"] = a.Actor, a.Action, a.Resource, a.Outcome
+	if a.Reason != "" {
+		m["reason"] = a.Reason
+	}
+	return json.Marshal(m)
+}
+
+// AuditLog appends audit events to a dedicated file, synced to disk before
+// Record returns, and copies them to an optional pipeline such as a
+// console.
+type AuditLog struct {
+	Pipeline io.Writer
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+func OpenAuditLog(path string, pipeline io.Writer) (*AuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLog{Pipeline: pipeline, f: f}, nil
+}
+
+// Record validates a and stores it durably. The event is only passed on
+// to Pipeline once it is on disk.
+func (l *AuditLog) Record(a AuditEvent) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	l.mu.Lock()
+	if _, err = l.f.Write(b); err == nil {
+		err = l.f.Sync()
+	}
+	l.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if l.Pipeline != nil {
+		l.Pipeline.Write(b)
+	}
+	return nil
+}
+
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
+
+// ErrNotAudit is returned by AuditLog.Write for events that are not marked
+// as audit events.
+var ErrNotAudit = errors.New("consoleEx: not an audit event")
+
+// RouteAudit returns a Decorator copying events marked with AuditFieldName
+// to sink, which usually is an fsync'd file such as an AuditLog's. A sink
+// error fails the write so audit events are never lost silently.
+func RouteAudit(sink io.Writer) Decorator {
+	marker := []byte(`"` + AuditFieldName + `":true`)
+	return func(next io.Writer) io.Writer {
+		return writerFunc(func(p []byte) (int, error) {
+			if bytes.Contains(p, marker) {
+				if _, err := sink.Write(p); err != nil {
+					return 0, err
+				}
+			}
+			return next.Write(p)
+		})
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+// Write lets the audit file be used as a RouteAudit sink: p must be an
+// audit event, which is appended and synced.
+func (l *AuditLog) Write(p []byte) (int, error) {
+	event, err := DecodeEvent(p)
+	if err != nil {
+		return 0, err
+	}
+	if event[AuditFieldName] != true {
+		return 0, ErrNotAudit
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.f.Write(p); err != nil {
+		return 0, err
+	}
+	return len(p), l.f.Sync()
+}
+
+// auditHeader renders the "actor action resource -> outcome" part of an
+// audit event on the console.
+func auditHeader(event map[string]interface{}, mode colorMode) string {
+	outcome := valueString(event["outcome"])
+	color := cGreen
+	if outcome != AuditSuccess {
+		color = cRed
+	}
+	return fmt.Sprintf("%s %s %s -> %s",
+		colorize(valueString(event["actor"]), cBold, mode),
+		valueString(event["action"]),
+		colorize(valueString(event["resource"]), cCyan, mode),
+		colorize(outcome, color, mode))
+}
+
+// withoutAuditFields drops the fields shown in the audit header.
+func withoutAuditFields(fields []string) []string {
+	out := fields[:0]
+	for _, f := range fields {
+		switch f {
+		case AuditFieldName, "actor", "action", "resource", "outcome":
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
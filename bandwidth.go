@@ -0,0 +1,98 @@
+package consoleEx
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// bandwidthLimiter is a token bucket pacing traffic to rate bytes per
+// second with bursts of up to one second worth of data.
+type bandwidthLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newBandwidthLimiter(rate int64) *bandwidthLimiter {
+	return &bandwidthLimiter{rate: float64(rate), tokens: float64(rate), last: time.Now()}
+}
+
+// wait blocks until n bytes may be sent.
+func (l *bandwidthLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+	if delay == 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// chunk is the largest piece sent at once, so pacing stays smooth.
+func (l *bandwidthLimiter) chunk() int {
+	c := int(l.rate / 10)
+	if c < 512 {
+		c = 512
+	}
+	return c
+}
+
+func (l *bandwidthLimiter) write(ctx context.Context, w io.Writer, p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := len(p)
+		if c := l.chunk(); n > c {
+			n = c
+		}
+		if err := l.wait(ctx, n); err != nil {
+			return written, err
+		}
+		m, err := w.Write(p[:n])
+		written += m
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// limitedReader paces reads, used for HTTP request bodies.
+type limitedReader struct {
+	ctx context.Context
+	r   io.Reader
+	l   *bandwidthLimiter
+}
+
+func (r limitedReader) Read(p []byte) (int, error) {
+	if c := r.l.chunk(); len(p) > c {
+		p = p[:c]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.l.wait(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
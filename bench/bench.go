@@ -0,0 +1,100 @@
+// Package bench measures consoleEx against zerolog's ConsoleWriter on
+// representative events. Run it with `consoleex bench` or
+// `go test -bench . ./bench`, or call Compare with your own events and
+// writer configurations to benchmark a specific field mix and catch
+// regressions.
+package bench
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"text/tabwriter"
+
+	"github.com/dwdcth/consoleEx"
+	"github.com/rs/zerolog"
+)
+
+// Event is a named sample log line.
+type Event struct {
+	Name string
+	JSON []byte
+}
+
+// Candidate is a writer configuration under test.
+type Candidate struct {
+	Name string
+	New  func(out io.Writer) io.Writer
+}
+
+// Result is the measurement of one candidate on one event.
+type Result struct {
+	Candidate   string
+	Event       string
+	NsPerOp     int64
+	AllocsPerOp int64
+	BytesPerOp  int64
+}
+
+// DefaultEvents covers short messages, callers, wide events and nested
+// values.
+var DefaultEvents = []Event{
+	{"message", []byte(`{"level":"info","time":"2024-06-01T12:00:00Z","message":"request handled"}`)},
+	{"caller", []byte(`{"level":"warn","time":"2024-06-01T12:00:00Z","caller":"/src/app/server.go:42","message":"slow request"}`)},
+	{"fields", []byte(`{"level":"info","time":"2024-06-01T12:00:00Z","message":"request","method":"GET","path":"/api/v1/users","status":200,"latency_ms":12.5,"bytes":5123,"request_id":"3f2a9c","user":"alice"}`)},
+	{"nested", []byte(`{"level":"error","time":"2024-06-01T12:00:00Z","message":"upstream failed","error":"dial tcp: i/o timeout","upstream":{"host":"db-1","port":5432},"retries":[1,2,4]}`)},
+}
+
+// DefaultCandidates compares colored and plain consoleEx output with the
+// equivalent zerolog.ConsoleWriter settings.
+var DefaultCandidates = []Candidate{
+	{"consoleEx", func(out io.Writer) io.Writer { return consoleEx.ConsoleWriterEx{Out: out} }},
+	{"consoleEx/nocolor", func(out io.Writer) io.Writer { return consoleEx.ConsoleWriterEx{Out: out, NoColor: true} }},
+	{"zerolog", func(out io.Writer) io.Writer { return zerolog.ConsoleWriter{Out: out} }},
+	{"zerolog/nocolor", func(out io.Writer) io.Writer { return zerolog.ConsoleWriter{Out: out, NoColor: true} }},
+}
+
+// Compare benchmarks every candidate on every event.
+func Compare(events []Event, candidates []Candidate) []Result {
+	var results []Result
+	for _, e := range events {
+		for _, c := range candidates {
+			results = append(results, Measure(e, c))
+		}
+	}
+	return results
+}
+
+// Measure benchmarks one candidate on one event.
+func Measure(e Event, c Candidate) Result {
+	w := c.New(io.Discard)
+	r := testing.Benchmark(func(b *testing.B) { run(b, w, e) })
+	return Result{
+		Candidate:   c.Name,
+		Event:       e.Name,
+		NsPerOp:     r.NsPerOp(),
+		AllocsPerOp: r.AllocsPerOp(),
+		BytesPerOp:  r.AllocedBytesPerOp(),
+	}
+}
+
+// run writes e to w b.N times.
+func run(b *testing.B, w io.Writer, e Event) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(e.JSON)))
+	for i := 0; i < b.N; i++ {
+		if _, err := w.Write(e.JSON); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// WriteTable prints results as an aligned table.
+func WriteTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "event\tcandidate\tns/op\tallocs/op\tB/op\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t\n", r.Event, r.Candidate, r.NsPerOp, r.AllocsPerOp, r.BytesPerOp)
+	}
+	return tw.Flush()
+}
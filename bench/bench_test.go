@@ -0,0 +1,80 @@
+package bench
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/dwdcth/consoleEx"
+)
+
+// BenchmarkWrite runs every default candidate on every default event, as
+// in BenchmarkWrite/fields/consoleEx.
+func BenchmarkWrite(b *testing.B) {
+	for _, e := range DefaultEvents {
+		for _, c := range DefaultCandidates {
+			e, c := e, c
+			b.Run(e.Name+"/"+c.Name, func(b *testing.B) {
+				run(b, c.New(io.Discard), e)
+			})
+		}
+	}
+}
+
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// BenchmarkParallelWrite compares producers sharing one mutex in front of
+// a writer with a ShardedWriter in front of it, for a writer costing
+// nothing and for a file.
+func BenchmarkParallelWrite(b *testing.B) {
+	event := DefaultEvents[0].JSON
+	for _, next := range []struct {
+		name string
+		open func(b *testing.B) io.Writer
+	}{
+		{"discard", func(b *testing.B) io.Writer { return io.Discard }},
+		{"file", func(b *testing.B) io.Writer {
+			f, err := os.Create(filepath.Join(b.TempDir(), "bench.log"))
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Cleanup(func() { f.Close() })
+			return f
+		}},
+	} {
+		for _, c := range []struct {
+			name string
+			wrap func(io.Writer) io.WriteCloser
+		}{
+			{"mutex", func(w io.Writer) io.WriteCloser { return nopCloser{&lockedWriter{w: w}} }},
+			{"sharded", func(w io.Writer) io.WriteCloser { return consoleEx.NewShardedWriter(w, 0, 0) }},
+		} {
+			b.Run(next.name+"/"+c.name, func(b *testing.B) {
+				w := c.wrap(next.open(b))
+				b.ReportAllocs()
+				b.SetBytes(int64(len(event)))
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						w.Write(event)
+					}
+				})
+				w.Close()
+			})
+		}
+	}
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,91 @@
+//go:build js && wasm
+
+package consoleEx
+
+import (
+	"strings"
+	"syscall/js"
+
+	. "github.com/rs/zerolog"
+)
+
+// BrowserConsole writes events to the browser's devtools console, with
+// console.debug, info, warn or error picked by level so the devtools
+// level filter works, and CSS styling in place of escape codes.
+type BrowserConsole struct {
+	// NoStyle leaves out the CSS.
+	NoStyle bool
+}
+
+func NewBrowserConsole() BrowserConsole {
+	return BrowserConsole{}
+}
+
+var browserLevelCSS = map[string]string{
+	"trace": "color: #3b82f6",
+	"debug": "color: #a855f7",
+	"info":  "color: #16a34a",
+	"warn":  "color: #ca8a04; font-weight: bold",
+	"error": "color: #dc2626; font-weight: bold",
+	"fatal": "color: #dc2626; font-weight: bold",
+	"panic": "color: #dc2626; font-weight: bold",
+}
+
+func (c BrowserConsole) Write(p []byte) (int, error) {
+	console := js.Global().Get("console")
+	event, err := DecodeEvent(p)
+	if err != nil {
+		console.Call("log", string(trimNewline(p)))
+		return len(p), nil
+	}
+	level, _ := event[LevelFieldName].(string)
+	var format strings.Builder
+	var args []interface{}
+	style := func(css string) {
+		if !c.NoStyle {
+			format.WriteString("%c")
+			args = append(args, css)
+		}
+	}
+	if level != "" {
+		style(browserLevelCSS[level])
+		format.WriteString(strings.ToUpper(level))
+		style("")
+		format.WriteByte(' ')
+	}
+	if caller, ok := event[CallerFieldName]; ok {
+		style("color: gray")
+		format.WriteString(valueString(caller))
+		style("")
+		format.WriteByte(' ')
+	}
+	format.WriteString(escapePercent(valueString(event[MessageFieldName])))
+	for _, field := range fieldNames(event) {
+		format.WriteByte(' ')
+		style("color: gray")
+		format.WriteString(escapePercent(field) + "=")
+		style("")
+		format.WriteString(escapePercent(quoteValue(event[field])))
+	}
+	console.Call(browserMethod(level), append([]interface{}{format.String()}, args...)...)
+	return len(p), nil
+}
+
+func browserMethod(level string) string {
+	switch level {
+	case "trace", "debug":
+		return "debug"
+	case "info":
+		return "info"
+	case "warn":
+		return "warn"
+	case "error", "fatal", "panic":
+		return "error"
+	}
+	return "log"
+}
+
+// escapePercent keeps text from being read as console format directives.
+func escapePercent(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
@@ -0,0 +1,14 @@
+//go:build !(js && wasm)
+
+package consoleEx
+
+// BrowserConsole is only available in js/wasm builds.
+type BrowserConsole struct {
+	NoStyle bool
+}
+
+func NewBrowserConsole() BrowserConsole {
+	return BrowserConsole{}
+}
+
+func (c BrowserConsole) Write(p []byte) (int, error) { return 0, errSinkClosed }
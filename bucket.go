@@ -0,0 +1,63 @@
+package consoleEx
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrOutOfOrder is reported to HTTPWriter.OnError for events older than a
+// bucket that was already sent.
+var ErrOutOfOrder = errors.New("consoleEx: event older than last sent bucket")
+
+type timeBucket struct {
+	end   time.Time
+	lines [][]byte
+}
+
+// bucketize splits lines by event time into sorted buckets ready to send,
+// lines to hold for a later flush and lines that arrived too late. Events
+// without a readable timestamp count as written now.
+func (w *HTTPWriter) bucketize(lines [][]byte, now time.Time, final bool) (ready []timeBucket, held, late [][]byte) {
+	type stamped struct {
+		t    time.Time
+		line []byte
+	}
+	groups := make(map[int64][]stamped)
+	for _, line := range lines {
+		t := now
+		if event, err := DecodeEvent(line); err == nil {
+			if et, ok := EventTime(event); ok {
+				t = et
+			}
+		}
+		if t.Before(w.shipped) {
+			late = append(late, line)
+			continue
+		}
+		k := t.Truncate(w.BucketSize).UnixNano()
+		groups[k] = append(groups[k], stamped{t, line})
+	}
+	keys := make([]int64, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, k := range keys {
+		g := groups[k]
+		sort.SliceStable(g, func(i, j int) bool { return g[i].t.Before(g[j].t) })
+		end := time.Unix(0, k).Add(w.BucketSize)
+		if !final && now.Before(end.Add(w.OutOfOrderTolerance)) {
+			for _, e := range g {
+				held = append(held, e.line)
+			}
+			continue
+		}
+		b := timeBucket{end: end}
+		for _, e := range g {
+			b.lines = append(b.lines, e.line)
+		}
+		ready = append(ready, b)
+	}
+	return ready, held, late
+}
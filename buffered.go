@@ -0,0 +1,120 @@
+package consoleEx
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// BufferedWriter collects events in memory and writes them to the next
+// writer in one call when its buffer is full, periodically, on Flush and
+// on Close, turning a syscall per event into one per batch. Events are
+// never split between batches. Fatal and panic events are written at
+// once, with everything buffered before them, and the next writer is
+// flushed.
+type BufferedWriter struct {
+	next io.Writer
+	size int
+
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	done chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// NewBufferedWriter returns a BufferedWriter holding up to size bytes,
+// 64KiB if size is not positive, and flushing every interval, or 1 second.
+func NewBufferedWriter(next io.Writer, size int, interval time.Duration) *BufferedWriter {
+	if size <= 0 {
+		size = 64 * 1024
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+	w := &BufferedWriter{next: next, size: size, done: make(chan struct{})}
+	w.buf.Grow(size)
+	w.wg.Add(1)
+	go w.loop(interval)
+	return w
+}
+
+func (w *BufferedWriter) loop(interval time.Duration) {
+	defer w.wg.Done()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			w.Flush()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *BufferedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.buf.Len() > 0 && w.buf.Len()+len(p) > w.size {
+		if err := w.flushLocked(); err != nil {
+			return 0, err
+		}
+	}
+	priority := IsPriority(p)
+	if len(p) >= w.size || priority {
+		if err := w.flushLocked(); err != nil {
+			return 0, err
+		}
+		if _, err := w.next.Write(p); err != nil {
+			return 0, err
+		}
+		if f, ok := w.next.(Flusher); ok && priority {
+			f.Flush()
+		}
+		return len(p), nil
+	}
+	w.buf.Write(p)
+	return len(p), nil
+}
+
+func (w *BufferedWriter) flushLocked() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.next.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// Flush writes out the buffered events.
+func (w *BufferedWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flushLocked()
+}
+
+// Close stops the flusher and writes out the remaining events. The next
+// writer is not closed.
+func (w *BufferedWriter) Close() error {
+	w.once.Do(func() {
+		close(w.done)
+		w.wg.Wait()
+	})
+	return w.Flush()
+}
+
+// Pressure reports the buffered bytes against the buffer size.
+func (w *BufferedWriter) Pressure() Pressure {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return newPressure(w.buf.Len(), w.size, 0)
+}
+
+// Buffered returns a Decorator batching writes, see BufferedWriter.
+func Buffered(size int, interval time.Duration) Decorator {
+	return func(next io.Writer) io.Writer {
+		return NewBufferedWriter(next, size, interval)
+	}
+}
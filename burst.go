@@ -0,0 +1,156 @@
+package consoleEx
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+// ProfilesFieldName lists the files a BurstProfiler captured.
+var ProfilesFieldName = "profiles"
+
+// BurstProfiler passes events to Next and, when Threshold events at
+// ErrorLevel or above arrive within Window, writes the Profiles named in
+// runtime/pprof to Dir, then a warn event listing their paths in
+// ProfilesFieldName, so the goroutines and heap of the moment things went
+// wrong are kept next to the log. After a capture it waits Cooldown before
+// capturing again.
+//
+//	w := consoleEx.NewBurstProfiler(file, "/var/log/app")
+type BurstProfiler struct {
+	Next      io.Writer
+	Dir       string
+	Threshold int
+	Window    time.Duration
+	Cooldown  time.Duration
+	Profiles  []string
+
+	mu     sync.Mutex
+	errors []time.Time
+	last   time.Time
+}
+
+// NewBurstProfiler captures goroutine and heap profiles on 20 errors in
+// 10 seconds, at most every 5 minutes.
+func NewBurstProfiler(next io.Writer, dir string) *BurstProfiler {
+	return &BurstProfiler{
+		Next:      next,
+		Dir:       dir,
+		Threshold: 20,
+		Window:    10 * time.Second,
+		Cooldown:  5 * time.Minute,
+		Profiles:  []string{"goroutine", "heap"},
+	}
+}
+
+func (b *BurstProfiler) Write(p []byte) (int, error) {
+	return b.WriteLevel(eventLevel(p), p)
+}
+
+// WriteLevel implements zerolog's LevelWriter, counting errors by the
+// level zerolog passes instead of decoding p.
+func (b *BurstProfiler) WriteLevel(level Level, p []byte) (int, error) {
+	var n int
+	var err error
+	if lw, ok := b.Next.(LevelWriter); ok {
+		n, err = lw.WriteLevel(level, p)
+	} else {
+		n, err = b.Next.Write(p)
+	}
+	if level == NoLevel || level < ErrorLevel {
+		return n, err
+	}
+	if count, ok := b.burst(time.Now()); ok {
+		b.capture(count)
+	}
+	return n, err
+}
+
+// burst records an error at now and reports whether it completes a burst
+// outside the cooldown, with the errors counted.
+func (b *BurstProfiler) burst(now time.Time) (int, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	i := 0
+	for i < len(b.errors) && now.Sub(b.errors[i]) > b.Window {
+		i++
+	}
+	b.errors = append(b.errors[i:], now)
+	if len(b.errors) < b.Threshold || !b.last.IsZero() && now.Sub(b.last) < b.Cooldown {
+		return 0, false
+	}
+	count := len(b.errors)
+	b.errors = b.errors[:0]
+	b.last = now
+	return count, true
+}
+
+func (b *BurstProfiler) capture(count int) {
+	now := time.Now()
+	stamp := now.UTC().Format("20060102T150405.000Z")
+	var paths []string
+	var failed error
+	if err := os.MkdirAll(b.Dir, 0755); err != nil {
+		failed = err
+	}
+	for _, name := range b.Profiles {
+		if failed != nil {
+			break
+		}
+		prof := pprof.Lookup(name)
+		if prof == nil {
+			continue
+		}
+		path := filepath.Join(b.Dir, name+"-"+stamp+".pb.gz")
+		if err := writeProfile(prof, path); err != nil {
+			failed = err
+			continue
+		}
+		paths = append(paths, path)
+	}
+	event := map[string]interface{}{
+		LevelFieldName:     WarnLevel.String(),
+		TimestampFieldName: now.Format(TimeFieldFormat),
+		"component":        DiagComponent,
+		MessageFieldName:   "error burst, profiles captured",
+		"errors":           count,
+		ProfilesFieldName:  paths,
+	}
+	if failed != nil {
+		event[ErrorFieldName] = failed.Error()
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	b.Next.Write(append(data, '\n'))
+}
+
+func writeProfile(prof *pprof.Profile, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = prof.WriteTo(f, 0)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// ProfileBursts returns a Decorator capturing profiles to dir on error
+// bursts, see BurstProfiler.
+func ProfileBursts(dir string) Decorator {
+	return func(next io.Writer) io.Writer {
+		return NewBurstProfiler(next, dir)
+	}
+}
@@ -0,0 +1,83 @@
+package consoleEx
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	. "github.com/rs/zerolog"
+)
+
+// CallerFuncFieldName is the field holding the calling function, for
+// loggers adding it next to the caller, e.g. with a hook setting
+// runtime.FuncForPC(pc).Name().
+var CallerFuncFieldName = "func"
+
+var fileLinePattern = regexp.MustCompile(`:\d+$`)
+
+// callerParts splits the caller of event into file:line and function. A
+// CallerMarshalFunc may put the function before or after file:line,
+// separated by a space; CallerFuncFieldName is used when it does not.
+func (w ConsoleWriterEx) callerParts(event map[string]interface{}) (caller, fn string) {
+	if v, ok := event[CallerFieldName]; ok {
+		caller = valueString(v)
+		if i := strings.LastIndexByte(caller, ' '); i > 0 {
+			a, b := caller[:i], strings.TrimSpace(caller[i+1:])
+			if fileLinePattern.MatchString(b) {
+				caller, fn = b, a
+			} else if fileLinePattern.MatchString(a) {
+				caller, fn = a, b
+			}
+		}
+	}
+	if fn == "" {
+		if v, ok := event[CallerFuncFieldName].(string); ok {
+			fn = v
+		}
+	}
+	if w.TrimPaths {
+		caller = trimPath(caller)
+	}
+	return caller, shortFuncName(fn, w.FuncWidth)
+}
+
+// trimPath keeps the file name and parent directory of path.
+func trimPath(path string) string {
+	path = strings.ReplaceAll(path, "\\", "/")
+	i := strings.LastIndexByte(path, '/')
+	if i <= 0 {
+		return path
+	}
+	if j := strings.LastIndexByte(path[:i], '/'); j >= 0 {
+		return path[j+1:]
+	}
+	return path
+}
+
+// shortFuncName drops the import path from a function name such as
+// "github.com/org/pkg.(*T).Method" and, if width is positive, cuts it to
+// width runes keeping the end.
+func shortFuncName(fn string, width int) string {
+	paren := strings.IndexByte(fn, '(')
+	if paren < 0 {
+		paren = len(fn)
+	}
+	if i := strings.LastIndexByte(fn[:paren], '/'); i >= 0 {
+		fn = fn[i+1:]
+	}
+	if width > 0 && utf8.RuneCountInString(fn) > width {
+		r := []rune(fn)
+		fn = "…" + string(r[len(r)-width+1:])
+	}
+	return fn
+}
+
+func withoutField(fields []string, name string) []string {
+	out := fields[:0]
+	for _, f := range fields {
+		if f != name {
+			out = append(out, f)
+		}
+	}
+	return out
+}
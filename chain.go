@@ -0,0 +1,61 @@
+package consoleEx
+
+import (
+	"io"
+
+	. "github.com/rs/zerolog"
+)
+
+// Decorator wraps a writer with cross-cutting behavior such as filtering,
+// redaction or buffering.
+type Decorator func(io.Writer) io.Writer
+
+// Chain wraps sink with decorators in the order they are listed: events
+// pass through the first decorator first and reach sink last, so
+// Chain(sink, a, b) is a(b(sink)).
+func Chain(sink io.Writer, decorators ...Decorator) io.Writer {
+	w := sink
+	for i := len(decorators) - 1; i >= 0; i-- {
+		w = decorators[i](w)
+	}
+	return w
+}
+
+// ChainBuilder assembles a decorator chain step by step.
+//
+//	w := NewChain().Use(Redact(nil)).Use(Tee(audit)).Then(file)
+type ChainBuilder struct {
+	decorators []Decorator
+}
+
+func NewChain(decorators ...Decorator) *ChainBuilder {
+	return &ChainBuilder{decorators: append([]Decorator(nil), decorators...)}
+}
+
+// Use appends decorators, which see events after the ones added before.
+func (b *ChainBuilder) Use(decorators ...Decorator) *ChainBuilder {
+	b.decorators = append(b.decorators, decorators...)
+	return b
+}
+
+// Then terminates the chain at sink.
+func (b *ChainBuilder) Then(sink io.Writer) io.Writer {
+	return Chain(sink, b.decorators...)
+}
+
+// Redact returns a Decorator masking events with r, or with
+// DefaultRedactor if r is nil.
+func Redact(r *Redactor) Decorator {
+	return func(next io.Writer) io.Writer {
+		return RedactWriter{Next: next, Redactor: r}
+	}
+}
+
+// Tee returns a Decorator that also copies every event, as it is at this
+// point of the chain, to w. Levels passed by zerolog reach writers
+// implementing LevelWriter.
+func Tee(w io.Writer) Decorator {
+	return func(next io.Writer) io.Writer {
+		return MultiLevelWriter(w, next)
+	}
+}
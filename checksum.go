@@ -0,0 +1,86 @@
+package consoleEx
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// ChecksumFieldName holds the CRC32 a ChecksumWriter adds to events.
+var ChecksumFieldName = "crc"
+
+// checksumTrailer starts the CRC32 ConsoleWriterEx appends with Checksum.
+const checksumTrailer = " #crc="
+
+// ChecksumWriter adds ChecksumFieldName to each JSON event, the IEEE CRC32
+// in hex of the event as it was, so consumers can detect lines truncated
+// or corrupted by a crash or in transport, see VerifyChecksum. Other
+// writes pass unchanged.
+type ChecksumWriter struct {
+	Next io.Writer
+}
+
+func (w ChecksumWriter) Write(p []byte) (int, error) {
+	body := bytes.TrimRight(p, " \r\n\t")
+	sum := crc32.ChecksumIEEE(body)
+	if _, err := w.Next.Write(injectField(p, ChecksumFieldName, checksumHex(sum))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func checksumHex(sum uint32) string {
+	return fmt.Sprintf("%08x", sum)
+}
+
+// appendLineChecksums appends text to dst, ending each of its lines with
+// checksumTrailer and the CRC32 of the line, then a newline.
+func appendLineChecksums(dst, text []byte) []byte {
+	for _, line := range bytes.Split(bytes.TrimSuffix(text, []byte{'\n'}), []byte{'\n'}) {
+		dst = append(dst, line...)
+		dst = append(dst, checksumTrailer+checksumHex(crc32.ChecksumIEEE(line))+"\n"...)
+	}
+	return dst
+}
+
+// trimLineChecksums removes the checksumTrailer ending each line of text.
+func trimLineChecksums(text []byte) []byte {
+	lines := bytes.Split(bytes.TrimSuffix(text, []byte{'\n'}), []byte{'\n'})
+	for i, line := range lines {
+		if n := len(line) - len(checksumTrailer) - 8; n >= 0 && string(line[n:n+len(checksumTrailer)]) == checksumTrailer {
+			lines[i] = line[:n]
+		}
+	}
+	return bytes.Join(lines, []byte{'\n'})
+}
+
+// VerifyChecksum checks the CRC32 ending line, added by a ChecksumWriter
+// or by ConsoleWriterEx with Checksum set. It reports whether line has
+// one and whether it matches.
+func VerifyChecksum(line []byte) (present, ok bool) {
+	line = bytes.TrimRight(line, "\r\n")
+	if i := len(line) - len(checksumTrailer) - 8; i >= 0 && string(line[i:i+len(checksumTrailer)]) == checksumTrailer {
+		return true, string(line[i+len(checksumTrailer):]) == checksumHex(crc32.ChecksumIEEE(line[:i]))
+	}
+	suffix := `"` + ChecksumFieldName + `":"`
+	end := len(line) - 10
+	start := end - len(suffix)
+	if start < 1 || line[len(line)-1] != '}' || line[end+8] != '"' || string(line[start:end]) != suffix {
+		return false, false
+	}
+	orig := append([]byte(nil), line[:start]...)
+	if orig[len(orig)-1] == ',' {
+		orig = orig[:len(orig)-1]
+	}
+	orig = append(orig, '}')
+	return true, string(line[end:end+8]) == checksumHex(crc32.ChecksumIEEE(orig))
+}
+
+// Checksum returns a Decorator adding CRC32s to events, see
+// ChecksumWriter.
+func Checksum() Decorator {
+	return func(next io.Writer) io.Writer {
+		return ChecksumWriter{Next: next}
+	}
+}
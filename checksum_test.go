@@ -0,0 +1,46 @@
+package consoleEx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func verifyLines(t *testing.T, name, out string, want int) {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != want {
+		t.Fatalf("%s: %d lines, want %d: %q", name, len(lines), want, out)
+	}
+	for _, line := range lines {
+		if present, ok := VerifyChecksum([]byte(line)); !present || !ok {
+			t.Errorf("%s: VerifyChecksum(%q) = %v, %v", name, line, present, ok)
+		}
+	}
+}
+
+func TestChecksumExpandedEvent(t *testing.T) {
+	var console, plain bytes.Buffer
+	w := ConsoleWriterEx{Out: &console, PlainOut: &plain, Checksum: true, Layout: LayoutExpanded}
+	w.Write([]byte(`{"level":"error","message":"failed","user":"alice","attempt":3}`))
+	verifyLines(t, "console", console.String(), 3)
+	verifyLines(t, "plain", plain.String(), 3)
+}
+
+func TestChecksumAfterSuppression(t *testing.T) {
+	var console bytes.Buffer
+	g := NewLineGovernor(1)
+	w := ConsoleWriterEx{Out: &console, Checksum: true, Governor: g}
+	w.Write([]byte(`{"level":"info","message":"one"}`))
+	w.Write([]byte(`{"level":"info","message":"two"}`))
+	g.mu.Lock()
+	g.window = g.window.Add(-time.Second)
+	g.mu.Unlock()
+	w.Write([]byte(`{"level":"info","message":"three"}`))
+	out := console.String()
+	if !strings.Contains(out, "1 lines suppressed") {
+		t.Fatalf("no suppression notice in %q", out)
+	}
+	verifyLines(t, "console", out, 3)
+}
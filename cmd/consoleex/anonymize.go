@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/dwdcth/consoleEx"
+)
+
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listFlag) Set(s string) error { *l = append(*l, s); return nil }
+
+func anonymize(args []string) error {
+	fs := flag.NewFlagSet("anonymize", flag.ExitOnError)
+	output := fs.String("o", "", "write the sanitized copy to `file` instead of stdout")
+	mask := fs.String("mask", "", "replacement for redacted values")
+	var fields, patterns listFlag
+	fs.Var(&fields, "field", "additional field `name` to mask (repeatable)")
+	fs.Var(&patterns, "pattern", "additional `regexp` to mask in values (repeatable)")
+	fs.Parse(args)
+
+	r := &consoleEx.Redactor{
+		Fields:   append(append([]string(nil), consoleEx.DefaultRedactor.Fields...), fields...),
+		Patterns: append([]*regexp.Regexp(nil), consoleEx.DefaultRedactor.Patterns...),
+		Mask:     *mask,
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return err
+		}
+		r.Patterns = append(r.Patterns, re)
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	bw := bufio.NewWriter(out)
+	in := []io.Reader{os.Stdin}
+	if fs.NArg() > 0 {
+		in = in[:0]
+		for _, name := range fs.Args() {
+			f, err := os.Open(name)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = append(in, f)
+		}
+	}
+	sc := bufio.NewScanner(io.MultiReader(in...))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		bw.Write(r.RedactLine(append(sc.Bytes(), '\n')))
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dwdcth/consoleEx/bench"
+)
+
+func benchCmd(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	fs.Parse(args)
+	events := bench.DefaultEvents
+	if fs.NArg() > 0 {
+		events = nil
+		for _, name := range fs.Args() {
+			f, err := os.Open(name)
+			if err != nil {
+				return err
+			}
+			sc := bufio.NewScanner(f)
+			sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+			for i := 1; sc.Scan(); i++ {
+				events = append(events, bench.Event{
+					Name: fmt.Sprintf("%s:%d", name, i),
+					JSON: append([]byte(nil), sc.Bytes()...),
+				})
+			}
+			f.Close()
+			if err := sc.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return bench.WriteTable(os.Stdout, bench.Compare(events, bench.DefaultCandidates))
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dwdcth/consoleEx"
+)
+
+func convert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	from := fs.String("from", "json", "input `format`, only json is supported")
+	to := fs.String("to", "logfmt", "output `format`: json, text, logfmt, csv, html or msgpack")
+	output := fs.String("o", "", "write to `file` instead of stdout")
+	fs.Parse(args)
+	if *from != "json" {
+		return fmt.Errorf("unsupported input format %q", *from)
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	bw := bufio.NewWriter(out)
+	sink, err := consoleEx.NewFormatWriter(*to, bw)
+	if err != nil {
+		return err
+	}
+	in := []io.Reader{os.Stdin}
+	if fs.NArg() > 0 {
+		in = in[:0]
+		for _, name := range fs.Args() {
+			f, err := os.Open(name)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = append(in, f)
+		}
+	}
+	sc := bufio.NewScanner(io.MultiReader(in...))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		sink.Write(sc.Bytes())
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if err := sink.Close(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConvertJSONLines(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.log")
+	out := filepath.Join(dir, "out.log")
+	os.WriteFile(in, []byte("{\"a\":1}\n{\"a\":2}\n"), 0666)
+	if err := convert([]string{"-to", "json", "-o", out, in}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"a\":1}\n{\"a\":2}\n"; string(data) != want {
+		t.Fatalf("output = %q, want %q", data, want)
+	}
+}
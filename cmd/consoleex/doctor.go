@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dwdcth/consoleEx"
+)
+
+// doctor opens the given sinks, or the comma separated list in
+// $CONSOLEEX_SINKS, and runs consoleEx.SelfTest against them.
+func doctor(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	timeout := fs.Duration("timeout", 10*time.Second, "give up on checks after `duration`")
+	fs.Parse(args)
+
+	uris := fs.Args()
+	if len(uris) == 0 && os.Getenv("CONSOLEEX_SINKS") != "" {
+		uris = strings.Split(os.Getenv("CONSOLEEX_SINKS"), ",")
+	}
+	if len(uris) == 0 {
+		uris = []string{"stdout://"}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	report := &consoleEx.SelfTestReport{}
+	sinks := make(map[string]io.Writer)
+	for _, uri := range uris {
+		uri = strings.TrimSpace(uri)
+		s, err := consoleEx.OpenSinkContext(ctx, uri)
+		if err != nil {
+			report.Results = append(report.Results, consoleEx.CheckResult{Sink: uri, Check: "open", Err: err})
+			continue
+		}
+		defer s.Close()
+		sinks[uri] = s
+	}
+	report.Results = append(report.Results, consoleEx.SelfTest(ctx, sinks).Results...)
+	report.WriteTo(os.Stdout)
+	if !report.OK() {
+		return errors.New("pipeline unhealthy")
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dwdcth/consoleEx"
+)
+
+func grep(args []string) error {
+	fs := flag.NewFlagSet("grep", flag.ExitOnError)
+	context := fs.Int("C", 0, "print `n` lines of context around matches")
+	since := fs.String("since", "", "only events at or after `time`")
+	until := fs.String("until", "", "only events before `time`")
+	noColor := fs.Bool("no-color", false, "disable colors")
+	pager := fs.Bool("pager", false, "page output through $PAGER on a terminal")
+	fs.Parse(args)
+
+	from, err := parseWhen(*since)
+	if err != nil {
+		return err
+	}
+	to, err := parseWhen(*until)
+	if err != nil {
+		return err
+	}
+	var preds []consoleEx.Predicate
+	var files []string
+	for _, a := range fs.Args() {
+		if _, err := os.Stat(a); err != nil {
+			if p, perr := consoleEx.ParsePredicate(a); perr == nil {
+				preds = append(preds, p)
+				continue
+			}
+		}
+		files = append(files, a)
+	}
+	out := stdout(*pager)
+	defer out.Close()
+	g := &grepper{
+		out:     consoleEx.ConsoleWriterEx{Out: out, NoColor: *noColor},
+		preds:   preds,
+		from:    from,
+		to:      to,
+		context: *context,
+	}
+	if len(files) == 0 {
+		return g.scan(os.Stdin)
+	}
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		if !from.IsZero() {
+			if idx, err := consoleEx.ReadIndex(name); err == nil {
+				f.Seek(consoleEx.SeekOffset(idx, from), io.SeekStart)
+			}
+		}
+		err = g.scan(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type grepper struct {
+	out      consoleEx.ConsoleWriterEx
+	preds    []consoleEx.Predicate
+	from, to time.Time
+	context  int
+}
+
+type numberedLine struct {
+	n    int
+	line []byte
+}
+
+func (g *grepper) scan(r io.Reader) error {
+	var before []numberedLine
+	after, last := 0, -1
+	emit := func(n int, line []byte) {
+		if g.context > 0 && last >= 0 && n > last+1 {
+			io.WriteString(g.out.Out, "--\n")
+		}
+		if _, err := consoleEx.DecodeEvent(line); err != nil {
+			g.out.Out.Write(append(line, '\n'))
+		} else {
+			g.out.Write(line)
+		}
+		last = n
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for n := 0; sc.Scan(); n++ {
+		line := append([]byte(nil), sc.Bytes()...)
+		switch {
+		case g.match(line):
+			for _, b := range before {
+				emit(b.n, b.line)
+			}
+			before = before[:0]
+			emit(n, line)
+			after = g.context
+		case after > 0:
+			after--
+			emit(n, line)
+		case g.context > 0:
+			if len(before) == g.context {
+				before = before[1:]
+			}
+			before = append(before, numberedLine{n, line})
+		}
+	}
+	return sc.Err()
+}
+
+func (g *grepper) match(line []byte) bool {
+	event, err := consoleEx.DecodeEvent(line)
+	if err != nil {
+		return false
+	}
+	if !g.from.IsZero() || !g.to.IsZero() {
+		t, ok := consoleEx.EventTime(event)
+		if !ok || (!g.from.IsZero() && t.Before(g.from)) || (!g.to.IsZero() && !t.Before(g.to)) {
+			return false
+		}
+	}
+	for _, p := range g.preds {
+		if !p(event) {
+			return false
+		}
+	}
+	return true
+}
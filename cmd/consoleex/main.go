@@ -0,0 +1,90 @@
+// Command consoleex searches and reshapes JSON log files written through
+// zerolog, rendering them with consoleEx.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dwdcth/consoleEx"
+	"github.com/mattn/go-colorable"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"grep", "grep [-C n] [-since t] [-until t] [-pager] predicate... [file...]", grep},
+	{"merge", "merge [-pretty] [-pager] file...", merge},
+	{"stats", "stats [-top n] [file...]", statsCmd},
+	{"convert", "convert [-from json] [-to logfmt|csv|html|text|json|msgpack] [-o file] [file...]", convert},
+	{"anonymize", "anonymize [-o file] [-field name] [-pattern re] [file...]", anonymize},
+	{"bench", "bench [sample.log...]", benchCmd},
+	{"doctor", "doctor [-timeout d] [sink-uri...]", doctor},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "consoleex %s: %s\n", c.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	usage()
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  consoleex %s\n", c.usage)
+	}
+	os.Exit(2)
+}
+
+// parseWhen accepts RFC3339, "2006-01-02 15:04", a clock time today such
+// as "14:00", or a duration ago such as "90m".
+func parseWhen(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			y, m, d := time.Now().Date()
+			return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, time.Local), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// stdout returns the writer for rendered output: a pager if asked for and
+// stdout is a terminal, colorable stdout otherwise. Close waits for the
+// pager.
+func stdout(pager bool) io.WriteCloser {
+	if pager {
+		return consoleEx.StartPager(os.Stdout)
+	}
+	return nopCloser{colorable.NewColorableStdout()}
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
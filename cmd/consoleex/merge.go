@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"io"
+
+	"github.com/dwdcth/consoleEx"
+)
+
+func merge(args []string) error {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	pretty := fs.Bool("pretty", false, "render merged events instead of writing JSON")
+	noColor := fs.Bool("no-color", false, "disable colors with -pretty")
+	pager := fs.Bool("pager", false, "page output through $PAGER on a terminal")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return errors.New("no input files")
+	}
+	w := stdout(*pager)
+	defer w.Close()
+	if !*pretty {
+		return consoleEx.MergeFiles(w, fs.Args()...)
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(consoleEx.MergeFiles(pw, fs.Args()...))
+	}()
+	out := consoleEx.ConsoleWriterEx{Out: w, NoColor: *noColor}
+	sc := bufio.NewScanner(pr)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		if _, err := out.Write(sc.Bytes()); err != nil {
+			w.Write(append(sc.Bytes(), '\n'))
+		}
+	}
+	return sc.Err()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dwdcth/consoleEx"
+	"github.com/rs/zerolog"
+)
+
+type stats struct {
+	levels    map[string]int
+	messages  map[string]int
+	errFields map[string]int
+	numbers   map[string][]float64
+	total     int
+	invalid   int
+}
+
+func statsCmd(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	top := fs.Int("top", 10, "show the top `n` messages and error fields")
+	fs.Parse(args)
+	s := &stats{
+		levels:    make(map[string]int),
+		messages:  make(map[string]int),
+		errFields: make(map[string]int),
+		numbers:   make(map[string][]float64),
+	}
+	r := consoleEx.NewLogReader(os.Stdin)
+	if fs.NArg() > 0 {
+		var err error
+		if r, err = consoleEx.OpenLogReader(fs.Args()...); err != nil {
+			return err
+		}
+	}
+	defer r.Close()
+	for r.Scan() {
+		s.add(r.Event().Fields)
+	}
+	if err := r.Err(); err != nil {
+		return err
+	}
+	s.invalid = r.Skipped()
+	s.report(os.Stdout, *top)
+	return nil
+}
+
+func (s *stats) add(event map[string]interface{}) {
+	s.total++
+	level, _ := event[zerolog.LevelFieldName].(string)
+	s.levels[level]++
+	if msg, ok := event[zerolog.MessageFieldName].(string); ok {
+		s.messages[msg]++
+	}
+	lvl, _ := zerolog.ParseLevel(level)
+	for k, v := range event {
+		switch k {
+		case zerolog.LevelFieldName, zerolog.TimestampFieldName, zerolog.MessageFieldName, zerolog.CallerFieldName:
+			continue
+		}
+		if n, ok := v.(json.Number); ok {
+			if f, err := n.Float64(); err == nil {
+				s.numbers[k] = append(s.numbers[k], f)
+			}
+		}
+		if lvl >= zerolog.ErrorLevel && lvl < zerolog.NoLevel {
+			s.errFields[fmt.Sprintf("%s=%v", k, v)]++
+		}
+	}
+}
+
+func (s *stats) report(w io.Writer, top int) {
+	fmt.Fprintf(w, "events: %d", s.total)
+	if s.invalid > 0 {
+		fmt.Fprintf(w, " (%d invalid lines)", s.invalid)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "\nlevels:")
+	levels := make([]string, 0, len(s.levels))
+	for l := range s.levels {
+		levels = append(levels, l)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		a, _ := zerolog.ParseLevel(levels[i])
+		b, _ := zerolog.ParseLevel(levels[j])
+		return a < b
+	})
+	for _, l := range levels {
+		name := strings.ToUpper(l)
+		if name == "" {
+			name = "(none)"
+		}
+		fmt.Fprintf(w, "  %-7s %8d %5.1f%%\n", name, s.levels[l], 100*float64(s.levels[l])/float64(s.total))
+	}
+
+	fmt.Fprintln(w, "\ntop messages:")
+	for _, kv := range topN(s.messages, top) {
+		fmt.Fprintf(w, "  %8d  %s\n", kv.n, kv.key)
+	}
+	if len(s.errFields) > 0 {
+		fmt.Fprintln(w, "\ntop error fields:")
+		for _, kv := range topN(s.errFields, top) {
+			fmt.Fprintf(w, "  %8d  %s\n", kv.n, kv.key)
+		}
+	}
+
+	if len(s.numbers) > 0 {
+		fmt.Fprintln(w, "\nnumeric fields:")
+		fmt.Fprintf(w, "  %-20s %8s %10s %10s %10s %10s\n", "field", "count", "p50", "p90", "p99", "max")
+		fields := make([]string, 0, len(s.numbers))
+		for k := range s.numbers {
+			fields = append(fields, k)
+		}
+		sort.Strings(fields)
+		for _, k := range fields {
+			v := s.numbers[k]
+			sort.Float64s(v)
+			fmt.Fprintf(w, "  %-20s %8d %10g %10g %10g %10g\n", k, len(v),
+				percentile(v, 50), percentile(v, 90), percentile(v, 99), v[len(v)-1])
+		}
+	}
+}
+
+type keyCount struct {
+	key string
+	n   int
+}
+
+func topN(m map[string]int, n int) []keyCount {
+	kc := make([]keyCount, 0, len(m))
+	for k, c := range m {
+		kc = append(kc, keyCount{k, c})
+	}
+	sort.Slice(kc, func(i, j int) bool {
+		if kc[i].n == kc[j].n {
+			return kc[i].key < kc[j].key
+		}
+		return kc[i].n > kc[j].n
+	})
+	if len(kc) > n {
+		kc = kc[:n]
+	}
+	return kc
+}
+
+// percentile uses the nearest-rank method on sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	i := int(float64(len(sorted))*p/100+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
@@ -0,0 +1,51 @@
+package consoleEx
+
+import (
+	"compress/gzip"
+	"io"
+	"sync"
+)
+
+// Codec compresses batches written by network and file sinks. Name is the
+// token used in URIs (?codec=gzip) and in the HTTP Content-Encoding header.
+// gzip, zstd and snappy are built in, zstd and snappy unless built with
+// consoleex_minimal; register others with RegisterCodec.
+type Codec interface {
+	Name() string
+	NewWriter(w io.Writer) io.WriteCloser
+}
+
+var codecRegistry = struct {
+	sync.RWMutex
+	m map[string]Codec
+}{m: map[string]Codec{"gzip": GzipCodec{Level: gzip.DefaultCompression}}}
+
+// RegisterCodec makes c available by name.
+func RegisterCodec(c Codec) {
+	codecRegistry.Lock()
+	defer codecRegistry.Unlock()
+	codecRegistry.m[c.Name()] = c
+}
+
+// LookupCodec returns the codec registered as name.
+func LookupCodec(name string) (Codec, bool) {
+	codecRegistry.RLock()
+	defer codecRegistry.RUnlock()
+	c, ok := codecRegistry.m[name]
+	return c, ok
+}
+
+// GzipCodec compresses with compress/gzip at the given level.
+type GzipCodec struct {
+	Level int
+}
+
+func (GzipCodec) Name() string { return "gzip" }
+
+func (c GzipCodec) NewWriter(w io.Writer) io.WriteCloser {
+	zw, err := gzip.NewWriterLevel(w, c.Level)
+	if err != nil {
+		zw = gzip.NewWriter(w)
+	}
+	return zw
+}
@@ -0,0 +1,44 @@
+//go:build !tinygo && !consoleex_minimal
+
+package consoleEx
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+func init() {
+	RegisterCodec(ZstdCodec{})
+	RegisterCodec(SnappyCodec{})
+}
+
+// ZstdCodec compresses with zstd at the given level, zstd.SpeedDefault if
+// zero.
+type ZstdCodec struct {
+	Level zstd.EncoderLevel
+}
+
+func (ZstdCodec) Name() string { return "zstd" }
+
+func (c ZstdCodec) NewWriter(w io.Writer) io.WriteCloser {
+	level := c.Level
+	if level == 0 {
+		level = zstd.SpeedDefault
+	}
+	zw, err := zstd.NewWriter(w, zstd.WithEncoderLevel(level))
+	if err != nil {
+		zw, _ = zstd.NewWriter(w)
+	}
+	return zw
+}
+
+// SnappyCodec compresses with the snappy framing format.
+type SnappyCodec struct{}
+
+func (SnappyCodec) Name() string { return "snappy" }
+
+func (SnappyCodec) NewWriter(w io.Writer) io.WriteCloser {
+	return snappy.NewBufferedWriter(w)
+}
@@ -0,0 +1,168 @@
+package consoleEx
+
+import (
+	"io"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// ColorProfile is the range of colors a terminal can show. Theme colors
+// beyond it are downgraded to the nearest color it has.
+type ColorProfile int
+
+const (
+	// ProfileTrueColor passes every color through, the zero value.
+	ProfileTrueColor ColorProfile = iota
+	// Profile256 limits colors to the 256-color palette.
+	Profile256
+	// ProfileANSI limits colors to the 16 basic ones.
+	ProfileANSI
+	// ProfileNoColor disables colors.
+	ProfileNoColor
+)
+
+// DetectColorProfile reports what out can show. NO_COLOR disables colors
+// and FORCE_COLOR (0 to 3, as in chalk) enables them even when out is not
+// a terminal; otherwise output that is not a terminal gets none. Terminals
+// are then graded by COLORTERM and TERM, and on Windows by whether they
+// run in Windows Terminal.
+func DetectColorProfile(out io.Writer) ColorProfile {
+	if v, ok := os.LookupEnv("FORCE_COLOR"); ok {
+		switch strings.ToLower(v) {
+		case "0", "false":
+			return ProfileNoColor
+		case "2":
+			return Profile256
+		case "3":
+			return ProfileTrueColor
+		}
+		if p := termProfile(); p != ProfileNoColor {
+			return p
+		}
+		return ProfileANSI
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return ProfileNoColor
+	}
+	f, ok := out.(interface{ Fd() uintptr })
+	if !ok || !isTerminal(f.Fd()) {
+		return ProfileNoColor
+	}
+	return termProfile()
+}
+
+func termProfile() ColorProfile {
+	term := os.Getenv("TERM")
+	switch ct := strings.ToLower(os.Getenv("COLORTERM")); {
+	case term == "dumb":
+		return ProfileNoColor
+	case ct == "truecolor" || ct == "24bit":
+		return ProfileTrueColor
+	case strings.Contains(term, "256color"):
+		return Profile256
+	case runtime.GOOS == "windows":
+		// go-colorable translates only the basic colors for the legacy
+		// console.
+		if os.Getenv("WT_SESSION") != "" {
+			return ProfileTrueColor
+		}
+		return ProfileANSI
+	}
+	return ProfileANSI
+}
+
+// downgrade returns the nearest color to c within p.
+func (c Color) downgrade(p ColorProfile) Color {
+	switch {
+	case c.kind == colorKindRGB && p >= Profile256:
+		r, g, b := c.rgb()
+		c = Color256(nearest256(r, g, b))
+		if p == Profile256 {
+			return c
+		}
+		fallthrough
+	case c.kind == colorKind256 && p >= ProfileANSI:
+		r, g, b := c.rgb()
+		return ANSIColor(nearestANSI(r, g, b))
+	}
+	return c
+}
+
+var ansiRGB = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// rgb returns the components of c, resolving palette colors.
+func (c Color) rgb() (int, int, int) {
+	switch c.kind {
+	case colorKindRGB:
+		return int(c.v >> 16), int(c.v >> 8 & 0xff), int(c.v & 0xff)
+	case colorKind256:
+		n := int(c.v)
+		switch {
+		case n < 16:
+			return ansiRGB[n][0], ansiRGB[n][1], ansiRGB[n][2]
+		case n < 232:
+			n -= 16
+			level := func(i int) int {
+				if i == 0 {
+					return 0
+				}
+				return 55 + i*40
+			}
+			return level(n / 36), level(n / 6 % 6), level(n % 6)
+		default:
+			v := 8 + (n-232)*10
+			return v, v, v
+		}
+	}
+	return 0, 0, 0
+}
+
+func nearest256(r, g, b int) uint8 {
+	cube := func(v int) int {
+		if v < 48 {
+			return 0
+		}
+		if v < 115 {
+			return 1
+		}
+		return (v - 35) / 40
+	}
+	best := uint8(16 + 36*cube(r) + 6*cube(g) + cube(b))
+	gray := (r + g + b) / 3
+	if gray > 238 {
+		gray = 238
+	}
+	gi := uint8(232)
+	if gray > 8 {
+		gi = uint8(232 + (gray-8)/10)
+	}
+	if dist(Color256(gi), r, g, b) < dist(Color256(best), r, g, b) {
+		return gi
+	}
+	return best
+}
+
+func nearestANSI(r, g, b int) int {
+	best, bestDist := 0, -1
+	for i, c := range ansiRGB {
+		dr, dg, db := c[0]-r, c[1]-g, c[2]-b
+		if d := dr*dr + dg*dg + db*db; bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	if best < 8 {
+		return 30 + best
+	}
+	return 90 + best - 8
+}
+
+func dist(c Color, r, g, b int) int {
+	cr, cg, cb := c.rgb()
+	return (cr-r)*(cr-r) + (cg-g)*(cg-g) + (cb-b)*(cb-b)
+}
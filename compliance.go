@@ -0,0 +1,175 @@
+package consoleEx
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CompliancePreset bundles the redaction, retention and durability
+// settings a regulated environment expects, so teams start from sane
+// defaults instead of assembling individual options. The presets are
+// starting points, not a certification.
+type CompliancePreset struct {
+	Name     string
+	Redactor *Redactor
+	// Retention is how long log files are kept: files opened with OpenFile
+	// delete older backups as they rotate, and Prune removes them from
+	// other sinks.
+	Retention time.Duration
+	// Fsync syncs files to disk after every event.
+	Fsync bool
+	// MaxSize is the size at which OpenFile rotates files,
+	// DefaultComplianceMaxSize if zero.
+	MaxSize int64
+}
+
+// DefaultComplianceMaxSize is the MaxSize of presets leaving it unset.
+const DefaultComplianceMaxSize = 100 << 20
+
+func extendRedactor(fields []string, patterns ...string) *Redactor {
+	r := &Redactor{
+		Fields:   append(append([]string(nil), DefaultRedactor.Fields...), fields...),
+		Patterns: append([]*regexp.Regexp(nil), DefaultRedactor.Patterns...),
+	}
+	for _, p := range patterns {
+		r.Patterns = append(r.Patterns, regexp.MustCompile(p))
+	}
+	return r
+}
+
+var compliancePresets = struct {
+	sync.RWMutex
+	m map[string]CompliancePreset
+}{m: map[string]CompliancePreset{
+	// PCI DSS 10.5.1: one year of audit history, card data never logged.
+	"pci": {
+		Name: "pci",
+		Redactor: extendRedactor([]string{"pan", "card_number", "cardnumber", "cc_number",
+			"cvv", "cvc", "cvv2", "track_data", "track1", "track2", "pin", "pin_block", "expiry", "exp_date"}),
+		Retention: 365 * 24 * time.Hour,
+		Fsync:     true,
+	},
+	// HIPAA 164.316: six years of documentation, no PHI in logs.
+	"hipaa": {
+		Name: "hipaa",
+		Redactor: extendRedactor([]string{"ssn", "social_security_number", "mrn", "medical_record_number",
+			"patient_name", "name", "dob", "date_of_birth", "birth_date", "address", "phone", "diagnosis",
+			"insurance_id", "health_plan_id"},
+			`\b\d{3}-\d{2}-\d{4}\b`,
+			`\+?\d{1,2}[ .\-]?\(?\d{3}\)?[ .\-]?\d{3}[ .\-]?\d{4}\b`),
+		Retention: 6 * 365 * 24 * time.Hour,
+		Fsync:     true,
+	},
+}}
+
+// RegisterCompliancePreset makes p available by name, replacing a built-in
+// preset of the same name.
+func RegisterCompliancePreset(p CompliancePreset) {
+	compliancePresets.Lock()
+	defer compliancePresets.Unlock()
+	compliancePresets.m[p.Name] = p
+}
+
+// LookupCompliancePreset returns the preset registered as name; "pci" and
+// "hipaa" are built in.
+func LookupCompliancePreset(name string) (CompliancePreset, bool) {
+	compliancePresets.RLock()
+	defer compliancePresets.RUnlock()
+	p, ok := compliancePresets.m[name]
+	return p, ok
+}
+
+// Wrap applies the preset's redaction in front of w.
+func (p CompliancePreset) Wrap(w io.Writer) io.Writer {
+	if p.Redactor == nil {
+		return w
+	}
+	return RedactWriter{Next: w, Redactor: p.Redactor}
+}
+
+// OpenFile opens path for appending with redaction as a RotatingFile
+// rolling at MaxSize and deleting backups older than Retention, syncing
+// after every event if Fsync is set.
+func (p CompliancePreset) OpenFile(path string) (io.WriteCloser, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, err
+	}
+	f := &RotatingFile{Path: path, MaxSize: p.MaxSize, MaxAge: p.Retention, Mode: 0640, SyncOnFatal: true}
+	if f.MaxSize <= 0 {
+		f.MaxSize = DefaultComplianceMaxSize
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	f.prune()
+	var w io.Writer = f
+	if p.Fsync {
+		w = syncWriter{f}
+	}
+	return multiCloser{p.Wrap(w), []io.Closer{f}}, nil
+}
+
+type syncWriter struct{ f *RotatingFile }
+
+func (w syncWriter) Write(b []byte) (int, error) {
+	return w.f.write(b, true)
+}
+
+// pruneStampLayouts are the stamps Prune recognizes between the base name
+// and the extension of a sink file: RotatingFile backups and the daily and
+// hourly DatedFile patterns.
+var pruneStampLayouts = []string{backupTimeLayout, "2006-01-02", "2006-01-02T15", "20060102"}
+
+// Prune removes the rotated, dated and compressed copies of the sink file
+// path, such as app-20240601T101500.000.log.gz next to app.log, last
+// modified longer than Retention ago, returning their paths. Only names
+// carrying one of the stamps RotatingFile and DatedFile produce match, so
+// path itself, other files in the directory and other sinks' files such
+// as app-worker.log are left alone. It does nothing if Retention is zero.
+func (p CompliancePreset) Prune(path string) ([]string, error) {
+	if p.Retention <= 0 {
+		return nil, nil
+	}
+	dir := filepath.Dir(path)
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(filepath.Base(path), ext)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-p.Retention)
+	var removed []string
+	for _, e := range entries {
+		name := strings.TrimSuffix(strings.TrimSuffix(e.Name(), ".idx"), ".gz")
+		if e.IsDir() || !strings.HasPrefix(name, base+"-") || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		if !isPruneStamp(strings.TrimSuffix(strings.TrimPrefix(name, base+"-"), ext)) {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil || fi.ModTime().After(cutoff) {
+			continue
+		}
+		old := filepath.Join(dir, e.Name())
+		if err := os.Remove(old); err != nil {
+			return removed, err
+		}
+		removed = append(removed, old)
+	}
+	return removed, nil
+}
+
+func isPruneStamp(stamp string) bool {
+	for _, layout := range pruneStampLayouts {
+		if _, err := time.ParseInLocation(layout, stamp, time.Local); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package consoleEx
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCompliancePruneOwnFiles(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+	for _, name := range []string{
+		"app.log",
+		"app-20240601T101500.000.log",
+		"app-20240601T101500.000.log.idx",
+		"app-2024-06-01.log.gz",
+		"other.log",
+		"app.txt",
+		"app-notes.txt",
+		"apps-2024-06-01.log",
+		"app-worker.log",
+		"app-2024-06-01-old.log",
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, nil, 0666)
+		os.Chtimes(path, old, old)
+	}
+	fresh := filepath.Join(dir, "app-2024-06-03.log")
+	os.WriteFile(fresh, nil, 0666)
+
+	p := CompliancePreset{Retention: 24 * time.Hour}
+	removed, err := p.Prune(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "app-2024-06-01.log.gz"),
+		filepath.Join(dir, "app-20240601T101500.000.log"),
+		filepath.Join(dir, "app-20240601T101500.000.log.idx"),
+	}
+	if !reflect.DeepEqual(removed, want) {
+		t.Fatalf("removed %v, want %v", removed, want)
+	}
+	left, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(left) != 8 {
+		t.Fatalf("left %v", left)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app-worker.log")); err != nil {
+		t.Fatalf("sibling sink file removed: %v", err)
+	}
+}
+
+func TestComplianceOpenFileRetention(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	stale := filepath.Join(dir, "audit-20200101T000000.000.log")
+	os.WriteFile(stale, nil, 0640)
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(stale, old, old)
+
+	p := CompliancePreset{Retention: 24 * time.Hour, Fsync: true, MaxSize: 64}
+	w, err := p.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatal("backup older than Retention kept on open")
+	}
+	event := []byte(`{"level":"info","message":"0123456789012345678901234567890123456789"}` + "\n")
+	w.Write(event)
+	w.Write(event)
+	w.Close()
+	backups, _ := filepath.Glob(filepath.Join(dir, "audit-*.log"))
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v, want one rotation at MaxSize", backups)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm()&0027 != 0 {
+		t.Fatalf("mode of %s = %v, %v", path, fi.Mode(), err)
+	}
+}
@@ -12,7 +12,6 @@ import (
 	"time"
 	. "github.com/rs/zerolog"
 	"os"
-	"github.com/mattn/go-colorable"
 )
 
 const (
@@ -36,45 +35,363 @@ var consoleBufPool = sync.Pool{
 
 // ConsoleWriterEx reads a JSON object per write operation and output an
 // optionally colored human readable version on the Out writer.
+//
+// Write is safe for concurrent use: every event is rendered into its own
+// buffer and handed to Out in a single Write call. Whether concurrent calls
+// on a shared Out can interleave is up to Out; os.File keeps them intact,
+// buffered or wrapping writers may not. Set Lock to LockOut to serialize
+// all ConsoleWriterEx values writing to the same Out.
 type ConsoleWriterEx struct {
 	Out     io.Writer
 	NoColor bool
+	// ColorTags renders colors as readable tags such as <red>ERRO</red>
+	// instead of escape codes, for stable golden tests.
+	ColorTags bool
+	// Lock selects how writes to Out are serialized.
+	Lock LockMode
+	// Translator, if set, localizes level labels and timestamps.
+	Translator Translator
+	// Summary, if set, counts every rendered event per level.
+	Summary *Summary
+	// MinLevel, if set, drops events below it; events without a level are
+	// kept.
+	MinLevel *Level
+	// Layout selects how much of each event is shown.
+	Layout Layout
+	// FieldRules, if set, hide fields unless their rule matches.
+	FieldRules FieldRules
+	// Diff, if set, dims fields unchanged since the previous event of the
+	// same component and highlights changed ones.
+	Diff *FieldDiff
+	// TimeFormat, if set, is the time.Format layout timestamps are
+	// rendered with, such as "15:04:05.000". Numeric timestamps default to
+	// RFC3339, string timestamps are shown as written.
+	TimeFormat string
+	// TimeLocation, if set, is the zone timestamps are converted to before
+	// rendering. Parsed timestamps otherwise go to time.Local; string
+	// timestamps are only re-rendered when TimeFormat or TimeLocation is set.
+	TimeLocation *time.Location
+	// FuncWidth, if positive, cuts caller function names to that many
+	// characters.
+	FuncWidth int
+	// TimeUnit, if set, is the unit of numeric timestamps, such as
+	// time.Millisecond for TimeFormatUnixMs. Otherwise it is guessed from
+	// the magnitude.
+	TimeUnit time.Duration
+	// PartsOrder, if set, lists the header parts to render and their order
+	// by field name, e.g. TimestampFieldName, LevelFieldName,
+	// MessageFieldName, CallerFieldName to show the caller last.
+	PartsOrder []string
+	// FieldsOrder lists fields rendered first, in this order, ahead of the
+	// alphabetically sorted rest.
+	FieldsOrder []string
+	// Stack, if set, renders error stacks as filtered traces.
+	Stack *StackFilter
+	// ErrorChain renders a wrapped error field one cause per line, the
+	// root cause highlighted.
+	ErrorChain bool
+	// FieldsExclude lists fields that are not rendered. FieldsInclude, if
+	// set, renders only the fields it lists. Either way the event passed to
+	// other writers is untouched.
+	FieldsExclude []string
+	FieldsInclude []string
+	// MultiErrors renders errors joined into an array or with newlines as
+	// a bulleted list under the event.
+	MultiErrors bool
+	// FormatTimestamp, FormatLevel, FormatCaller and FormatMessage, if set,
+	// render the raw header parts in place of the built-in formatting, as
+	// in zerolog's ConsoleWriter. FormatFieldName and FormatFieldValue do
+	// the same for each field.
+	FormatTimestamp  Formatter
+	FormatLevel      Formatter
+	FormatCaller     Formatter
+	FormatMessage    Formatter
+	FormatFieldName  Formatter
+	FormatFieldValue Formatter
+	// Theme, if set, replaces the built-in colors of the event line.
+	Theme *Theme
+	// Profile limits the colors used to what the terminal supports, see
+	// DetectColorProfile.
+	Profile ColorProfile
+	// LevelColors maps level names, including custom ones, to one of the
+	// basic SGR color codes, overriding the built-in colors.
+	LevelColors map[string]int
+	// FullLevelNames renders level names whole, as ERROR rather than ERRO.
+	// LevelWidth, if set, is the width labels are cut or padded to,
+	// defaulting to 4, or 5 with FullLevelNames.
+	FullLevelNames bool
+	LevelWidth     int
+	// TrimPaths renders the source files of callers and stack frames with
+	// only their parent directory, as pkg/file.go:12, and forward slashes.
+	TrimPaths bool
+	// Checksum ends each line of an event with " #crc=" and the CRC32 of
+	// the rendered line before it, so multi-line events such as those of
+	// LayoutExpanded or with stacks are checked line by line, see
+	// VerifyChecksum.
+	Checksum bool
+	// Governor, if set, caps the events rendered per second. Writers
+	// sharing one are capped together.
+	Governor *LineGovernor
+	// ErrOut, if set, receives the events at warn and above instead of Out,
+	// as stderr does for command line tools whose stdout is piped.
+	ErrOut io.Writer
+	// PlainOut, if set, also receives every rendered event, with the
+	// escape sequences stripped, as a human-readable log file next to a
+	// colored console. Events are rendered once for both. MinLevel and
+	// Governor only filter the console: PlainOut gets everything.
+	PlainOut io.Writer
 }
 
-func (w ConsoleWriterEx) Write(p []byte) (n int, err error) {
-	var event map[string]interface{}
+// Layout is a rendering preset of ConsoleWriterEx.
+type Layout int
+
+const (
+	// LayoutDefault prints time, level, caller, message and fields on one
+	// line.
+	LayoutDefault Layout = iota
+	// LayoutCompact leaves out the time and caller.
+	LayoutCompact
+	// LayoutExpanded prints each field on its own indented line.
+	LayoutExpanded
+)
+
+func (w ConsoleWriterEx) Write(p []byte) (int, error) {
+	buf := consoleBufPool.Get().(*bytes.Buffer)
+	defer func() {
+		buf.Reset()
+		consoleBufPool.Put(buf)
+	}()
+	out, start, err := w.render(buf, p)
+	if err != nil {
+		return 0, err
+	}
+	if buf.Len() > 0 {
+		if w.PlainOut != nil {
+			w.writePlain(buf.Bytes()[start:])
+		}
+		if out != nil {
+			if err := w.writeOut(out, buf); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(p), nil
+}
+
+// writePlain writes the rendered event to PlainOut without escape
+// sequences, with its checksums, if any, over the stripped lines.
+func (w ConsoleWriterEx) writePlain(rendered []byte) {
+	plainBuf := consoleBufPool.Get().(*bytes.Buffer)
+	defer func() {
+		plainBuf.Reset()
+		consoleBufPool.Put(plainBuf)
+	}()
+	plain := appendStripped(plainBuf.Bytes(), rendered)
+	if w.Checksum {
+		plain = appendLineChecksums(nil, trimLineChecksums(plain))
+	}
+	w.PlainOut.Write(plain)
+}
+
+// render writes the rendering of p to buf and returns the console writer
+// it is for, nil if the console filters it out, and where the event starts
+// in buf, after any console-only notice. Filtered events are rendered
+// anyway when PlainOut is set, and not at all otherwise.
+func (w ConsoleWriterEx) render(buf *bytes.Buffer, p []byte) (io.Writer, int, error) {
 	p = decodeIfBinaryToBytes(p)
-	d := json.NewDecoder(bytes.NewReader(p))
-	d.UseNumber()
-	err = d.Decode(&event)
+	event, err := DecodeEvent(p)
 	if err != nil {
-		return
+		return nil, 0, err
 	}
-	buf := consoleBufPool.Get().(*bytes.Buffer)
-	defer consoleBufPool.Put(buf)
+	if name, ok := event[MarkerFieldName].(string); ok {
+		buf.WriteString(markerRule(w.formatTime(event[TimestampFieldName]), name, w.colors()))
+		buf.WriteByte('\n')
+		return w.Out, 0, nil
+	}
+	dropped := false
 	lvlColor := cReset
-	level := "????"
-	if l, ok := event[LevelFieldName].(string); ok {
+	level := w.levelLabel("????")
+	l, hasLevel := event[LevelFieldName].(string)
+	if hasLevel {
+		if w.MinLevel != nil {
+			if lvl, err := ParseLevel(l); err == nil && lvl < *w.MinLevel {
+				if w.PlainOut == nil {
+					return nil, 0, nil
+				}
+				dropped = true
+			}
+		}
 		if !w.NoColor {
-			lvlColor = levelColor(l)
+			lvlColor = w.levelColor(l)
+		}
+		if w.Translator != nil {
+			level = w.fitLabel(w.Translator.TranslateLevel(l))
+		} else {
+			level = w.levelLabel(l)
+		}
+	}
+	if w.Governor != nil && !dropped {
+		ok, suppressed := w.Governor.admit(l, time.Now())
+		if !ok && w.PlainOut == nil {
+			return nil, 0, nil
+		}
+		dropped = !ok
+		if ok && suppressed > 0 {
+			notice := colorize(fmt.Sprintf("... %d lines suppressed", suppressed), cDarkGray, w.colors())
+			if w.Checksum {
+				buf.Write(appendLineChecksums(nil, []byte(notice)))
+			} else {
+				buf.WriteString(notice)
+				buf.WriteByte('\n')
+			}
+		}
+	}
+	start := buf.Len()
+	if hasLevel && w.Summary != nil && !dropped {
+		w.Summary.Observe(l, time.Now())
+	}
+	msg := w.paint(event[MessageFieldName], partMessage, cReset)
+	fields := fieldNames(event)
+	if w.FieldsExclude != nil || w.FieldsInclude != nil {
+		fields = selectFields(fields, w.FieldsInclude, w.FieldsExclude)
+	}
+	if w.FieldsOrder != nil {
+		fields = pinFields(fields, w.FieldsOrder)
+	}
+	if w.FieldRules != nil {
+		fields = w.FieldRules.filter(event, fields)
+	}
+	if event[AuditFieldName] == true {
+		l, level, lvlColor = "audit", "AUDT", cMagenta
+		if w.NoColor {
+			lvlColor = cReset
+		}
+		msg = auditHeader(event, w.colors())
+		fields = withoutAuditFields(fields)
+	}
+	caller, fn := w.callerParts(event)
+	if fn != "" {
+		fields = withoutField(fields, CallerFuncFieldName)
+	}
+	where := ""
+	if caller != "" {
+		where = w.paint(caller, partCaller, cReset)
+	}
+	if fn != "" {
+		if where != "" {
+			where += " "
 		}
-		level = strings.ToUpper(l)[0:4]
+		where += w.paint(fn, partFunc, cBlue)
+	}
+	if w.FormatCaller != nil && caller != "" {
+		where = w.FormatCaller(event[CallerFieldName])
+	}
+	ts := w.paint(w.formatTime(event[TimestampFieldName]), partTimestamp, cDarkGray)
+	if w.FormatTimestamp != nil {
+		ts = w.FormatTimestamp(event[TimestampFieldName])
+	}
+	lvl := w.paintLevel(level, l, lvlColor)
+	if w.FormatLevel != nil && event[AuditFieldName] != true {
+		lvl = w.FormatLevel(event[LevelFieldName])
+	}
+	if w.FormatMessage != nil && event[AuditFieldName] != true {
+		msg = w.FormatMessage(event[MessageFieldName])
+	}
+	if w.PartsOrder != nil {
+		w.writeParts(buf, ts, lvl, where, msg)
+	} else if w.Layout == LayoutCompact {
+		fmt.Fprintf(buf, "%s %s", lvl, msg)
+	} else if where != "" {
+		fmt.Fprintf(buf, "%s |%s| %s |%s ", ts, lvl, where, msg)
+	} else {
+		fmt.Fprintf(buf, "%s |%s| %s", ts, lvl, msg)
 	}
-	_, hasCaller := event[CallerFieldName]
-	if hasCaller {
-		fmt.Fprintf(buf, "%s |%s| %s |%s ",
-			colorize(formatTime(event[TimestampFieldName]), cDarkGray, !w.NoColor),
-			colorize(level, lvlColor, !w.NoColor),
-			colorize(event[CallerFieldName], cReset, !w.NoColor),
-			colorize(event[MessageFieldName], cReset, !w.NoColor))
 
+	fields, frames := w.takeStack(event, fields)
+	fields, errLists := w.takeMultiErrors(event, fields)
+	fields, chain := w.takeErrorChain(event, fields)
+	sep := " "
+	if w.Layout == LayoutExpanded {
+		sep = "\n    "
+	}
+	var changed map[string]bool
+	if w.Diff != nil {
+		changed = w.Diff.observe(event, fields)
+	}
+	for _, field := range fields {
+		value := quoteValue(event[field])
+		name := w.paint(field, partFieldName, cCyan)
+		if c, ok := changed[field]; ok {
+			if c {
+				value = colorize(value, cYellow, w.colors())
+			} else {
+				name = colorize(field, cDarkGray, w.colors())
+				value = colorize(value, cDarkGray, w.colors())
+			}
+		} else if w.Theme != nil {
+			value = w.Theme.FieldValue.render(value, w.colors(), w.Profile)
+		}
+		if w.FormatFieldName != nil {
+			name = w.FormatFieldName(field)
+		}
+		if w.FormatFieldValue != nil {
+			value = w.FormatFieldValue(event[field])
+		}
+		fmt.Fprintf(buf, "%s%s=", sep, name)
+		buf.WriteString(value)
+	}
+	if errLists != nil {
+		w.writeMultiErrors(buf, errLists)
+	}
+	if chain != nil {
+		w.writeErrorChain(buf, chain)
+	}
+	if frames != nil {
+		w.writeStack(buf, frames)
+	}
+	if w.Checksum {
+		event := append([]byte(nil), buf.Bytes()[start:]...)
+		buf.Truncate(start)
+		buf.Write(appendLineChecksums(nil, event))
 	} else {
-		fmt.Fprintf(buf, "%s |%s| %s",
-			colorize(formatTime(event[TimestampFieldName]), cDarkGray, !w.NoColor),
-			colorize(level, lvlColor, !w.NoColor),
-			colorize(event[MessageFieldName], cReset, !w.NoColor))
+		buf.WriteByte('\n')
+	}
+	if dropped {
+		return nil, start, nil
+	}
+	return w.out(l), start, nil
+}
+
+// WriteLevel implements zerolog's LevelWriter, dropping events below
+// MinLevel before they are decoded unless PlainOut wants them.
+func (w ConsoleWriterEx) WriteLevel(level Level, p []byte) (int, error) {
+	if w.MinLevel != nil && w.PlainOut == nil && level != NoLevel && level < *w.MinLevel {
+		return len(p), nil
+	}
+	return w.Write(p)
+}
+
+func (w ConsoleWriterEx) writeOut(out io.Writer, buf *bytes.Buffer) error {
+	if w.Lock == LockOut {
+		defer lockOut(out)()
+	}
+	_, err := buf.WriteTo(out)
+	return err
+}
+
+// out returns ErrOut for events at warn or above if it is set, else Out.
+func (w ConsoleWriterEx) out(level string) io.Writer {
+	if w.ErrOut != nil {
+		if l, err := ParseLevel(level); err == nil && l >= WarnLevel && l != NoLevel {
+			return w.ErrOut
+		}
 	}
+	return w.Out
+}
 
+// fieldNames returns the sorted names of the event fields that are not
+// rendered as part of the header.
+func fieldNames(event map[string]interface{}) []string {
 	fields := make([]string, 0, len(event))
 	for field := range event {
 		switch field {
@@ -84,52 +401,202 @@ func (w ConsoleWriterEx) Write(p []byte) (n int, err error) {
 		fields = append(fields, field)
 	}
 	sort.Strings(fields)
-	for _, field := range fields {
-		fmt.Fprintf(buf, " %s=", colorize(field, cCyan, !w.NoColor))
-		switch value := event[field].(type) {
-		case string:
-			if needsQuote(value) {
-				buf.WriteString(strconv.Quote(value))
-			} else {
-				buf.WriteString(value)
-			}
-		case json.Number:
-			fmt.Fprint(buf, value)
+	return fields
+}
+
+// valueString renders a decoded field value: strings as is, numbers
+// verbatim and everything else as JSON.
+func valueString(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	default:
+		b, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprintf("[error: %v]", err)
+		}
+		return string(b)
+	}
+}
+
+// quoteValue is valueString with strings quoted when needed.
+func quoteValue(v interface{}) string {
+	if s, ok := v.(string); ok && needsQuote(s) {
+		return strconv.Quote(s)
+	}
+	return valueString(v)
+}
+
+// DecodeEvent decodes one JSON log line, keeping numbers as json.Number.
+func DecodeEvent(p []byte) (map[string]interface{}, error) {
+	var event map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(p))
+	d.UseNumber()
+	err := d.Decode(&event)
+	return event, err
+}
+
+// EventTime returns the timestamp of a decoded event.
+func EventTime(event map[string]interface{}) (time.Time, bool) {
+	return parseTime(event[TimestampFieldName])
+}
+
+// parseTime converts a decoded timestamp field back into a time.Time.
+func parseTime(t interface{}) (time.Time, bool) {
+	switch t := t.(type) {
+	case string:
+		ts, err := time.Parse(TimeFieldFormat, t)
+		return ts, err == nil
+	case json.Number:
+		return unixTime(t, 0)
+	}
+	return time.Time{}, false
+}
+
+// unixTime converts a Unix timestamp in unit, or in the unit its magnitude
+// suggests if unit is 0: seconds up to year 5138, then milliseconds,
+// microseconds and nanoseconds.
+func unixTime(n json.Number, unit time.Duration) (time.Time, bool) {
+	i, err := n.Int64()
+	if err != nil {
+		f, err := n.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		if unit == 0 {
+			unit = time.Second
+		}
+		return time.Unix(0, int64(f*float64(unit))), true
+	}
+	if unit == 0 {
+		a := i
+		if a < 0 {
+			a = -a
+		}
+		switch {
+		case a < 1e11:
+			unit = time.Second
+		case a < 1e14:
+			unit = time.Millisecond
+		case a < 1e17:
+			unit = time.Microsecond
 		default:
-			b, err := json.Marshal(value)
-			if err != nil {
-				fmt.Fprintf(buf, "[error: %v]", err)
-			} else {
-				fmt.Fprint(buf, string(b))
-			}
+			unit = time.Nanosecond
 		}
 	}
-	buf.WriteByte('\n')
-	buf.WriteTo(w.Out)
-	n = len(p)
-	return
+	if unit >= time.Second {
+		return time.Unix(i*int64(unit/time.Second), 0), true
+	}
+	per := int64(time.Second / unit)
+	return time.Unix(i/per, i%per*int64(unit)), true
 }
 
-func formatTime(t interface{}) string {
+func (w ConsoleWriterEx) formatTime(t interface{}) string {
+	layout, loc := w.TimeFormat, w.TimeLocation
+	var ts time.Time
 	switch t := t.(type) {
 	case string:
-		return t
+		if w.Translator == nil && layout == "" && loc == nil {
+			return t
+		}
+		var err error
+		if ts, err = time.Parse(TimeFieldFormat, t); err != nil {
+			if ts, err = time.Parse(time.RFC3339Nano, t); err != nil {
+				return t
+			}
+		}
+		if layout == "" {
+			layout = TimeFieldFormat
+		}
 	case json.Number:
-		u, _ := t.Int64()
-		return time.Unix(u, 0).Format(time.RFC3339)
+		var ok bool
+		if ts, ok = unixTime(t, w.TimeUnit); !ok {
+			return t.String()
+		}
+		if layout == "" {
+			layout = time.RFC3339
+			if ts.Nanosecond() != 0 {
+				layout = time.RFC3339Nano
+			}
+		}
+	default:
+		return "<nil>"
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	ts = ts.In(loc)
+	if w.Translator != nil {
+		return w.Translator.TranslateTime(ts)
+	}
+	return ts.Format(layout)
+}
+
+type colorMode int
+
+const (
+	colorOff colorMode = iota
+	colorANSI
+	colorTags
+)
+
+var colorTagNames = map[int]string{
+	cBold:     "bold",
+	cRed:      "red",
+	cGreen:    "green",
+	cYellow:   "yellow",
+	cBlue:     "blue",
+	cMagenta:  "magenta",
+	cCyan:     "cyan",
+	cGray:     "gray",
+	cDarkGray: "darkgray",
+}
+
+func (w ConsoleWriterEx) colors() colorMode {
+	switch {
+	case w.NoColor || w.Profile == ProfileNoColor:
+		return colorOff
+	case w.ColorTags:
+		return colorTags
 	}
-	return "<nil>"
+	return colorANSI
 }
 
-func colorize(s interface{}, color int, enabled bool) string {
-	if !enabled {
+func colorize(s interface{}, color int, mode colorMode) string {
+	switch mode {
+	case colorOff:
 		return fmt.Sprintf("%v", s)
+	case colorTags:
+		name, ok := colorTagNames[color]
+		if !ok {
+			return fmt.Sprintf("%v", s)
+		}
+		return fmt.Sprintf("<%s>%v</%s>", name, s, name)
+	}
+	if str, ok := s.(string); ok && color >= 0 && color < len(sgrCodes) {
+		return sgrCodes[color] + str + "\x1b[0m"
 	}
 	return fmt.Sprintf("\x1b[%dm%v\x1b[0m", color, s)
 }
 
-func levelColor(level string) int {
+// sgrCodes holds the escape sequences of the basic colors, saving a
+// fmt.Sprintf per colored part.
+var sgrCodes = func() (codes [108]string) {
+	for i := range codes {
+		codes[i] = "\x1b[" + strconv.Itoa(i) + "m"
+	}
+	return
+}()
+
+func (w ConsoleWriterEx) levelColor(level string) int {
+	if c, ok := w.LevelColors[level]; ok {
+		return c
+	}
 	switch level {
+	case "trace":
+		return cBlue
 	case "debug":
 		return cMagenta
 	case "info":
@@ -138,9 +605,33 @@ func levelColor(level string) int {
 		return cYellow
 	case "error", "fatal", "panic":
 		return cRed
-	default:
+	case "":
 		return cReset
+	default:
+		return cBold
+	}
+}
+
+// levelLabel returns level upper-cased and fitted, see fitLabel.
+func (w ConsoleWriterEx) levelLabel(level string) string {
+	return w.fitLabel(strings.ToUpper(level))
+}
+
+// fitLabel cuts or pads label to LevelWidth runes, never cutting with
+// FullLevelNames.
+func (w ConsoleWriterEx) fitLabel(label string) string {
+	width := w.LevelWidth
+	if width <= 0 {
+		width = 4
+		if w.FullLevelNames {
+			width = 5
+		}
+	}
+	l := []rune(label)
+	if len(l) > width && !w.FullLevelNames {
+		l = l[:width]
 	}
+	return fmt.Sprintf("%-*s", width, string(l))
 }
 
 func needsQuote(s string) bool {
@@ -155,17 +646,29 @@ func decodeIfBinaryToBytes(in []byte) []byte {
 	return in
 }
 
+// NewWriter returns a writer sending every event to the console and,
+// if writeFile is set, as JSON to filename, which is created or appended
+// to; NewPipeline().ConsoleTee(filename) writes the file as the console
+// shows it instead, without colors. Closing it syncs and closes the file,
+// Reopen (see Reopener) reopens it after logrotate moved it. Use
+// NewPipeline to choose the destinations, their formats and minimum
+// levels.
+func NewWriter(filename string, writeFile bool) (io.WriteCloser, error) {
+	b := NewPipeline().Console()
+	if writeFile {
+		b.JSONFile(filename)
+	}
+	return b.Build()
+}
+
+// GetWriter is NewWriter exiting the process if the file cannot be opened.
+//
+// Deprecated: use NewWriter.
 func GetWriter(logFilename string, writeFile bool) io.Writer {
-	logFile, err := os.OpenFile(logFilename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	w, err := NewWriter(logFilename, writeFile)
 	if err != nil {
 		fmt.Printf("open file error=%s\r\n", err.Error())
 		os.Exit(-1)
 	}
-	writers := []io.Writer{
-		ConsoleWriterEx{Out: colorable.NewColorableStdout()},
-	}
-	if writeFile {
-		writers = append(writers, logFile)
-	}
-	return io.MultiWriter(writers...)
+	return w
 }
@@ -0,0 +1,26 @@
+package consoleEx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/rs/zerolog"
+)
+
+func TestPlainOutGetsFilteredEvents(t *testing.T) {
+	var console, plain bytes.Buffer
+	min := InfoLevel
+	w := ConsoleWriterEx{Out: &console, PlainOut: &plain, NoColor: true, MinLevel: &min, Governor: NewLineGovernor(1)}
+	w.WriteLevel(DebugLevel, []byte(`{"level":"debug","message":"one"}`))
+	w.Write([]byte(`{"level":"info","message":"two"}`))
+	w.Write([]byte(`{"level":"info","message":"three"}`))
+	for _, msg := range []string{"one", "two", "three"} {
+		if !strings.Contains(plain.String(), msg) {
+			t.Errorf("PlainOut lost %q: %q", msg, plain.String())
+		}
+	}
+	if got := console.String(); strings.Contains(got, "one") || !strings.Contains(got, "two") || strings.Contains(got, "three") {
+		t.Errorf("console = %q, want only two", got)
+	}
+}
@@ -0,0 +1,72 @@
+// Package consoletest provides helpers for using consoleEx in tests.
+package consoletest
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/dwdcth/consoleEx"
+	"github.com/rs/zerolog"
+)
+
+// Dir is the directory test logs are written to. It defaults to
+// $CONSOLETEST_DIR, or consoletest under the system temp directory.
+var Dir = os.Getenv("CONSOLETEST_DIR")
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// Writer returns a writer rendering events into a file named after the
+// test, with a random suffix so parallel test binaries sharing Dir never
+// write to the same file. The file is removed when the test passes and
+// kept, with its path reported through t.Log, when it fails.
+func Writer(t testing.TB) io.Writer {
+	t.Helper()
+	dir := Dir
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "consoletest")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("consoletest: %v", err)
+	}
+	f, err := os.CreateTemp(dir, unsafeChars.ReplaceAllString(t.Name(), "_")+"-*.log")
+	if err != nil {
+		t.Fatalf("consoletest: %v", err)
+	}
+	name := f.Name()
+	w := &lockedWriter{w: consoleEx.ConsoleWriterEx{Out: f, NoColor: true}}
+	t.Cleanup(func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.w = io.Discard
+		f.Close()
+		if t.Failed() {
+			t.Logf("log kept at %s", name)
+			return
+		}
+		os.Remove(name)
+	})
+	return w
+}
+
+// Logger returns a zerolog.Logger with timestamps writing to Writer(t).
+func Logger(t testing.TB) zerolog.Logger {
+	t.Helper()
+	return zerolog.New(Writer(t)).With().Timestamp().Logger()
+}
+
+// lockedWriter stops writes from goroutines outliving the test from
+// racing with the cleanup.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
@@ -0,0 +1,51 @@
+package consoletest
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriterFiles(t *testing.T) {
+	dir := t.TempDir()
+	old := Dir
+	Dir = dir
+	defer func() { Dir = old }()
+
+	var names []string
+	t.Run("pass", func(t *testing.T) {
+		a, b := Writer(t), Writer(t)
+		a.Write([]byte(`{"level":"info","message":"a"}` + "\n"))
+		b.Write([]byte(`{"level":"info","message":"b"}` + "\n"))
+		names, _ = filepath.Glob(filepath.Join(dir, "*.log"))
+		if len(names) != 2 {
+			t.Fatalf("files = %v, want one per Writer", names)
+		}
+		data, _ := os.ReadFile(names[0])
+		if !strings.Contains(string(data), "INF") {
+			t.Fatalf("log = %q, want a rendered event", data)
+		}
+	})
+	for _, name := range names {
+		if !strings.HasPrefix(filepath.Base(name), "TestWriterFiles_pass-") {
+			t.Errorf("file %s is not named after the test", name)
+		}
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("file %s kept after the test passed", name)
+		}
+	}
+}
+
+func TestWriterAfterCleanup(t *testing.T) {
+	Dir = t.TempDir()
+	defer func() { Dir = "" }()
+	var w io.Writer
+	t.Run("inner", func(t *testing.T) {
+		w = Writer(t)
+	})
+	if _, err := w.Write([]byte(`{"message":"late"}` + "\n")); err != nil {
+		t.Fatalf("late write: %v", err)
+	}
+}
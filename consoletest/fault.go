@@ -0,0 +1,114 @@
+package consoletest
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInjected is the error of writes a FaultWriter fails on purpose.
+	ErrInjected = errors.New("consoletest: injected fault")
+	// ErrDisconnected is the error of writes to a disconnected FaultWriter.
+	ErrDisconnected = errors.New("consoletest: disconnected")
+)
+
+// FaultWriter passes writes to Next, injecting faults on the way so tests
+// can check how a pipeline retries and fails over. Faults are drawn from a
+// generator seeded with Seed, so a test sees the same ones on every run.
+//
+//	w := consoletest.NewFaultWriter(sink, 1)
+//	w.ErrorRate = 0.2
+//	w.DisconnectAfter = 100
+type FaultWriter struct {
+	Next io.Writer
+	Seed int64
+	// ErrorRate is the share of writes failing with Err, or ErrInjected.
+	ErrorRate float64
+	Err       error
+	// PartialRate is the share of writes passing only part of p to Next
+	// and returning io.ErrShortWrite.
+	PartialRate float64
+	// Delay and Jitter hold each write back for Delay plus up to Jitter.
+	Delay  time.Duration
+	Jitter time.Duration
+	// DisconnectAfter, if positive, makes every write after that many fail
+	// with ErrDisconnected until Reconnect is called.
+	DisconnectAfter int
+
+	mu           sync.Mutex
+	rnd          *rand.Rand
+	writes       int
+	connected    int
+	faults       int
+	disconnected bool
+}
+
+// NewFaultWriter returns a FaultWriter injecting no faults until its rates
+// are set.
+func NewFaultWriter(next io.Writer, seed int64) *FaultWriter {
+	return &FaultWriter{Next: next, Seed: seed}
+}
+
+func (f *FaultWriter) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	if f.rnd == nil {
+		f.rnd = rand.New(rand.NewSource(f.Seed))
+	}
+	f.writes++
+	f.connected++
+	delay := f.Delay
+	if f.Jitter > 0 {
+		delay += time.Duration(f.rnd.Int63n(int64(f.Jitter) + 1))
+	}
+	if f.DisconnectAfter > 0 && f.connected > f.DisconnectAfter {
+		f.disconnected = true
+	}
+	var err error
+	n := len(p)
+	switch r := f.rnd.Float64(); {
+	case f.disconnected:
+		err = ErrDisconnected
+	case r < f.ErrorRate:
+		err = f.Err
+		if err == nil {
+			err = ErrInjected
+		}
+	case r < f.ErrorRate+f.PartialRate && len(p) > 0:
+		n = f.rnd.Intn(len(p))
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		f.faults++
+	}
+	f.mu.Unlock()
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	if err != nil && err != io.ErrShortWrite {
+		return 0, err
+	}
+	written, werr := f.Next.Write(p[:n])
+	if werr != nil {
+		return written, werr
+	}
+	return written, err
+}
+
+// Reconnect lets writes through again after a disconnect, which happens
+// again after another DisconnectAfter writes.
+func (f *FaultWriter) Reconnect() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.disconnected = false
+	f.connected = 0
+}
+
+// Writes reports the writes attempted and the faults injected into them.
+func (f *FaultWriter) Writes() (writes, faults int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.writes, f.faults
+}
@@ -0,0 +1,69 @@
+package consoletest
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func faults(seed int64) []error {
+	f := NewFaultWriter(io.Discard, seed)
+	f.ErrorRate = 0.3
+	f.PartialRate = 0.3
+	var errs []error
+	for i := 0; i < 50; i++ {
+		_, err := f.Write([]byte("event\n"))
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func TestFaultWriterSeeded(t *testing.T) {
+	a, b := faults(7), faults(7)
+	var injected, short int
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("write %d: %v then %v with the same seed", i, a[i], b[i])
+		}
+		switch a[i] {
+		case ErrInjected:
+			injected++
+		case io.ErrShortWrite:
+			short++
+		}
+	}
+	if injected == 0 || short == 0 {
+		t.Fatalf("injected %d errors and %d short writes, want both", injected, short)
+	}
+}
+
+func TestFaultWriterPartial(t *testing.T) {
+	var buf bytes.Buffer
+	f := NewFaultWriter(&buf, 1)
+	f.PartialRate = 1
+	n, err := f.Write([]byte("event\n"))
+	if err != io.ErrShortWrite || n >= len("event\n") || buf.Len() != n {
+		t.Fatalf("Write = %d, %v with %d bytes passed on", n, err, buf.Len())
+	}
+}
+
+func TestFaultWriterDisconnect(t *testing.T) {
+	var buf bytes.Buffer
+	f := NewFaultWriter(&buf, 1)
+	f.DisconnectAfter = 2
+	for i, want := range []error{nil, nil, ErrDisconnected, ErrDisconnected} {
+		if _, err := f.Write([]byte("x")); err != want {
+			t.Fatalf("write %d: %v, want %v", i, err, want)
+		}
+	}
+	f.Reconnect()
+	if _, err := f.Write([]byte("x")); err != nil {
+		t.Fatalf("after Reconnect: %v", err)
+	}
+	if writes, faults := f.Writes(); writes != 5 || faults != 2 {
+		t.Fatalf("Writes = %d, %d; want 5, 2", writes, faults)
+	}
+	if buf.String() != "xxx" {
+		t.Fatalf("passed on %q", buf.String())
+	}
+}
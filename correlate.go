@@ -0,0 +1,102 @@
+package consoleEx
+
+import (
+	"encoding/json"
+	"io"
+
+	. "github.com/rs/zerolog"
+)
+
+// ContextRefsFieldName is the field Correlator adds to error events.
+var ContextRefsFieldName = "context_refs"
+
+// Correlator records every event in Ring and annotates events at Trigger
+// or above with the sequence numbers of up to Max earlier events at
+// MinLevel or above sharing their Key value, in ContextRefsFieldName. The
+// numbers are the events' SeqFieldName if a Sequencer stamped them, so
+// they can be found in shipped logs, and RingBuffer sequence numbers
+// otherwise. Ring should not be fed by another writer as well.
+//
+//	Chain(sink, Sequence(), Correlate(NewRingBuffer(5000)))
+type Correlator struct {
+	Next     io.Writer
+	Ring     *RingBuffer
+	Key      string
+	Trigger  Level
+	MinLevel Level
+	Max      int
+}
+
+// NewCorrelator annotates errors with up to 20 earlier debug, info and
+// warn events of the same request_id.
+func NewCorrelator(next io.Writer, ring *RingBuffer) *Correlator {
+	return &Correlator{
+		Next:     next,
+		Ring:     ring,
+		Key:      "request_id",
+		Trigger:  ErrorLevel,
+		MinLevel: DebugLevel,
+		Max:      20,
+	}
+}
+
+func (c *Correlator) Write(p []byte) (int, error) {
+	q := p
+	if event, err := DecodeEvent(p); err == nil {
+		if refs := c.refs(event); len(refs) > 0 {
+			q = injectField(p, ContextRefsFieldName, refs)
+		}
+	}
+	c.Ring.Write(q)
+	if _, err := c.Next.Write(q); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *Correlator) refs(event map[string]interface{}) []interface{} {
+	s, ok := event[LevelFieldName].(string)
+	if !ok {
+		return nil
+	}
+	if l, err := ParseLevel(s); err != nil || l < c.Trigger || l == NoLevel {
+		return nil
+	}
+	key, ok := event[c.Key]
+	if !ok {
+		return nil
+	}
+	want := valueString(key)
+	entries := c.Ring.Snapshot()
+	var refs []interface{}
+	for i := len(entries) - 1; i >= 0 && (c.Max <= 0 || len(refs) < c.Max); i-- {
+		e := entries[i]
+		if e.Level < c.MinLevel || e.Level >= c.Trigger {
+			continue
+		}
+		prior, err := DecodeEvent(e.Data)
+		if err != nil {
+			continue
+		}
+		if v, ok := prior[c.Key]; !ok || valueString(v) != want {
+			continue
+		}
+		if seq, ok := prior[SeqFieldName].(json.Number); ok {
+			refs = append(refs, seq)
+		} else {
+			refs = append(refs, e.Seq)
+		}
+	}
+	for i, j := 0, len(refs)-1; i < j; i, j = i+1, j-1 {
+		refs[i], refs[j] = refs[j], refs[i]
+	}
+	return refs
+}
+
+// Correlate returns a Decorator annotating errors from ring, see
+// Correlator.
+func Correlate(ring *RingBuffer) Decorator {
+	return func(next io.Writer) io.Writer {
+		return NewCorrelator(next, ring)
+	}
+}
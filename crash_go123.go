@@ -0,0 +1,12 @@
+//go:build go1.23
+
+package consoleEx
+
+import (
+	"os"
+	"runtime/debug"
+)
+
+func setCrashOutput(f *os.File) error {
+	return debug.SetCrashOutput(f, debug.CrashOptions{})
+}
@@ -0,0 +1,10 @@
+//go:build !go1.23
+
+package consoleEx
+
+import "os"
+
+// setCrashOutput has nothing to hook into before Go 1.23.
+func setCrashOutput(f *os.File) error {
+	return f.Close()
+}
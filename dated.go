@@ -0,0 +1,254 @@
+package consoleEx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+// DatedFile writes to the file named by formatting Pattern, a time layout
+// such as "logs/app-2006-01-02.log" for daily or "logs/app-2006-01-02T15.log"
+// for hourly files, moving on to the next file as soon as the formatted
+// name changes. A file deleted or moved away while in use, as by a cleanup
+// job, is created again within a second.
+type DatedFile struct {
+	Pattern string
+	// UTC names files by UTC instead of local time.
+	UTC bool
+	// Link, if set, is kept pointing at the active file, e.g. logs/app.log.
+	Link string
+	// Header, if set, is written at the top of every new file.
+	Header *FileHeader
+	// Compress gzips each file the writer moves on from in the background,
+	// as RotatingFile does with backups.
+	Compress bool
+	// OnError, if set, is called when a file could not be compressed.
+	OnError func(err error, path string)
+	// SyncOnFatal syncs the file after fatal and panic events, so they
+	// survive the exit that follows.
+	SyncOnFatal bool
+	// Index, if positive, keeps a sidecar index of each file as LogFile
+	// does, removed when the file is compressed.
+	Index time.Duration
+	// MaxAge, if positive, deletes the files listed by Files last modified
+	// longer ago whenever the writer moves on to a new file.
+	MaxAge time.Duration
+	// Manifest, if set, is the path of a manifest updated with every file
+	// the writer moves on from, once compressed if Compress is set, and
+	// with the active file on Close, see UpdateManifest.
+	Manifest string
+
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	f       *os.File
+	idx     *fileIndex
+	path    string
+	checked time.Time
+}
+
+func NewDatedFile(pattern string) *DatedFile {
+	return &DatedFile{Pattern: pattern}
+}
+
+// PathAt returns the file path used for events written at t.
+func (w *DatedFile) PathAt(t time.Time) string {
+	if w.UTC {
+		t = t.UTC()
+	}
+	return t.Format(w.Pattern)
+}
+
+func (w *DatedFile) Write(p []byte) (int, error) {
+	return w.write(p, w.SyncOnFatal && IsPriority(p))
+}
+
+// WriteLevel implements zerolog's LevelWriter, see LogFile.WriteLevel.
+func (w *DatedFile) WriteLevel(level Level, p []byte) (int, error) {
+	return w.write(p, w.SyncOnFatal && isPriorityLevel(level))
+}
+
+func (w *DatedFile) write(p []byte, sync bool) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := time.Now()
+	if path := w.PathAt(now); path != w.path || w.f == nil || w.gone(now) {
+		if err := w.open(path); err != nil {
+			return 0, err
+		}
+	}
+	if w.Index > 0 && w.idx == nil {
+		idx, err := openFileIndex(w.path, w.Index)
+		if err != nil {
+			return 0, err
+		}
+		w.idx = idx
+	}
+	n, err := w.f.Write(p)
+	if w.idx != nil {
+		if ierr := w.idx.add(p[:n]); err == nil {
+			err = ierr
+		}
+	}
+	if err == nil && sync {
+		err = w.f.Sync()
+	}
+	return n, err
+}
+
+// gone reports, at most once a second, whether the active file is no
+// longer at its path.
+func (w *DatedFile) gone(now time.Time) bool {
+	if now.Sub(w.checked) < time.Second {
+		return false
+	}
+	w.checked = now
+	fi, err := os.Stat(w.path)
+	if err != nil {
+		return true
+	}
+	cur, err := w.f.Stat()
+	return err == nil && !os.SameFile(fi, cur)
+}
+
+func (w *DatedFile) open(path string) error {
+	w.idx.Close()
+	w.idx = nil
+	if w.f != nil {
+		w.f.Close()
+		w.f = nil
+		if w.Compress && path != w.path {
+			os.Remove(w.path + ".idx")
+			w.compress(w.path)
+		} else if path != w.path {
+			w.record(w.path)
+		}
+	}
+	if path != w.path && w.MaxAge > 0 {
+		w.prune(path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	if err := writeHeaderIfEmpty(f, w.Header); err != nil {
+		f.Close()
+		return err
+	}
+	w.f, w.path, w.checked = f, path, time.Now()
+	if w.Link != "" {
+		linkCurrent(path, w.Link)
+	}
+	return nil
+}
+
+// Path returns the file currently written to, empty before the first write.
+func (w *DatedFile) Path() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.path
+}
+
+// Files returns the paths of the files in the directory of the current
+// one whose names Pattern could have produced, compressed ones included,
+// in name order.
+func (w *DatedFile) Files() ([]string, error) {
+	dir := filepath.Dir(w.PathAt(time.Now()))
+	layout := filepath.Base(w.Pattern)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".gz")
+		if e.IsDir() || name == layout {
+			continue
+		}
+		if _, err := time.Parse(layout, name); err != nil {
+			continue
+		}
+		files = append(files, filepath.Join(dir, e.Name()))
+	}
+	return files, nil
+}
+
+// prune deletes the files MaxAge does not keep, other than current.
+// Failures are ignored so that logging never stops.
+func (w *DatedFile) prune(current string) {
+	files, err := w.Files()
+	if err != nil {
+		return
+	}
+	for _, path := range files {
+		if path == filepath.Clean(current) {
+			continue
+		}
+		if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) > w.MaxAge {
+			os.Remove(path)
+			os.Remove(path + ".idx")
+		}
+	}
+}
+
+func (w *DatedFile) compress(path string) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		if err := compressFile(path); err != nil {
+			if w.OnError != nil {
+				w.OnError(err, path)
+			}
+			w.record(path)
+		} else {
+			w.record(path + ".gz")
+		}
+	}()
+}
+
+// record adds a completed file to the manifest. Failures are ignored so
+// that logging never stops.
+func (w *DatedFile) record(path string) {
+	if w.Manifest != "" {
+		UpdateManifest(w.Manifest, path)
+	}
+}
+
+// Close closes the active file, which is left uncompressed, and waits for
+// files being compressed.
+func (w *DatedFile) Close() error {
+	w.mu.Lock()
+	var err error
+	if w.f != nil {
+		err = w.f.Close()
+		w.f = nil
+		if err == nil && w.Manifest != "" {
+			_, err = UpdateManifest(w.Manifest, w.path)
+		}
+	}
+	w.idx.Close()
+	w.idx = nil
+	w.mu.Unlock()
+	w.wg.Wait()
+	return err
+}
+
+// Reopen closes the file, which the next write opens again at its path.
+func (w *DatedFile) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.idx.Close()
+	w.idx = nil
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
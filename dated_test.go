@@ -0,0 +1,105 @@
+package consoleEx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// chdirTemp moves into a new temporary directory for the test, since
+// DatedFile patterns are time layouts and temporary paths hold digits.
+func chdirTemp(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestDatedFileMaxAge(t *testing.T) {
+	chdirTemp(t)
+	old := time.Now().Add(-72 * time.Hour)
+	for _, name := range []string{"app-2020-01-01.log", "app-2020-01-02.log.gz", "app-notes.log"} {
+		os.WriteFile(name, nil, 0666)
+		os.Chtimes(name, old, old)
+	}
+	w := NewDatedFile("app-2006-01-02.log")
+	w.MaxAge = 24 * time.Hour
+	w.Write([]byte(`{"message":"x"}` + "\n"))
+	w.Close()
+	left, _ := filepath.Glob("*")
+	want := []string{w.PathAt(time.Now()), "app-notes.log"}
+	if len(left) != 2 || left[0] != want[0] || left[1] != want[1] {
+		t.Fatalf("left %v, want %v", left, want)
+	}
+}
+
+// nextPeriod waits until w names events after another file than path.
+func nextPeriod(w *DatedFile, path string) {
+	for w.PathAt(time.Now()) == path {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDatedFileRollsOver(t *testing.T) {
+	chdirTemp(t)
+	w := NewDatedFile("app-2006-01-02T15-04-05.000.log")
+	w.Link = "app.log"
+	w.Compress = true
+	w.Write([]byte(`{"message":"one"}` + "\n"))
+	first := w.Path()
+	nextPeriod(w, first)
+	w.Write([]byte(`{"message":"two"}` + "\n"))
+	second := w.Path()
+	w.Close()
+	if first == second {
+		t.Fatal("no new file after the period ended")
+	}
+	if _, err := os.Stat(first + ".gz"); err != nil {
+		t.Fatalf("previous file not compressed: %v", err)
+	}
+	if data, _ := os.ReadFile(second); string(data) != `{"message":"two"}`+"\n" {
+		t.Fatalf("current file = %q", data)
+	}
+	if data, _ := os.ReadFile("app.log"); string(data) != `{"message":"two"}`+"\n" {
+		t.Fatalf("link reads %q, want the current file", data)
+	}
+}
+
+func TestDatedFileReopensAcrossBoundary(t *testing.T) {
+	chdirTemp(t)
+	const pattern = "app-2006-01-02T15-04-05.000.log"
+	w := NewDatedFile(pattern)
+	w.Write([]byte(`{"message":"one"}` + "\n"))
+	first := w.Path()
+	w.Close()
+
+	again := NewDatedFile(pattern)
+	if again.PathAt(time.Now()) == first {
+		again.Write([]byte(`{"message":"same period"}` + "\n"))
+		if again.Path() != first {
+			t.Fatalf("reopened in the same period at %s, want %s", again.Path(), first)
+		}
+	}
+	nextPeriod(again, first)
+	if err := again.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	again.Write([]byte(`{"message":"two"}` + "\n"))
+	again.Close()
+	if again.Path() == first {
+		t.Fatal("write after the boundary went to the old file")
+	}
+	files, err := again.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0] != first || files[1] != again.Path() {
+		t.Fatalf("Files = %v, want %s and %s", files, first, again.Path())
+	}
+}
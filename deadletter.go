@@ -0,0 +1,90 @@
+package consoleEx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// RejectedError is returned when a backend refused a batch in a way that
+// retrying will not fix, such as a 4xx answer.
+type RejectedError struct {
+	URL    string
+	Status string
+	// Body holds the start of the response, which usually names the
+	// offending field.
+	Body string
+}
+
+func (e *RejectedError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("consoleEx: %s: %s", e.URL, e.Status)
+	}
+	return fmt.Sprintf("consoleEx: %s: %s: %s", e.URL, e.Status, e.Body)
+}
+
+// IsPermanent reports whether err means the events were rejected for good
+// rather than lost to a transient failure.
+func IsPermanent(err error) bool {
+	var r *RejectedError
+	return errors.As(err, &r) || errors.Is(err, ErrOutOfOrder)
+}
+
+// DeadLetterFile appends events a sink rejected permanently to an NDJSON
+// file, one object per event holding the rejection time, sink, reason and
+// the original event, so they can be inspected and replayed.
+type DeadLetterFile struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func OpenDeadLetterFile(path string) (*DeadLetterFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &DeadLetterFile{f: f}, nil
+}
+
+type deadLetter struct {
+	Time   time.Time   `json:"time"`
+	Sink   string      `json:"sink,omitempty"`
+	Reason string      `json:"reason"`
+	Event  interface{} `json:"event"`
+}
+
+// Add records events rejected by sink with reason. Events that are not
+// valid JSON are stored as strings.
+func (d *DeadLetterFile) Add(sink string, reason error, events ...[]byte) error {
+	buf := make([]byte, 0, 256*len(events))
+	now := time.Now()
+	for _, e := range events {
+		e = trimNewline(e)
+		var event interface{} = json.RawMessage(e)
+		if !json.Valid(e) {
+			event = string(e)
+		}
+		b, err := json.Marshal(deadLetter{now, sink, reason.Error(), event})
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, b...), '\n')
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.f.Write(buf)
+	return err
+}
+
+func (d *DeadLetterFile) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.f.Close()
+}
@@ -0,0 +1,59 @@
+package consoleEx
+
+import (
+	"io"
+	"sync/atomic"
+
+	. "github.com/rs/zerolog"
+)
+
+type writerHolder struct {
+	w io.Writer
+}
+
+var defaultWriter atomic.Value
+
+func currentDefault() io.Writer {
+	if h, ok := defaultWriter.Load().(writerHolder); ok {
+		return h.w
+	}
+	defaultWriter.CompareAndSwap(nil, writerHolder{ConsoleWriterEx{Out: stdout()}})
+	return defaultWriter.Load().(writerHolder).w
+}
+
+// SetDefault atomically installs w as the shared pipeline behind Default
+// and returns the previous one. A nil w discards events.
+func SetDefault(w io.Writer) io.Writer {
+	if w == nil {
+		w = io.Discard
+	}
+	if old, ok := defaultWriter.Swap(writerHolder{w}).(writerHolder); ok {
+		return old.w
+	}
+	return ConsoleWriterEx{Out: stdout()}
+}
+
+// Default returns a writer forwarding to whatever was last passed to
+// SetDefault, colored stdout until then. Because the forwarding happens per
+// event, a logger bound once, e.g.
+//
+//	log.Logger = zerolog.New(consoleEx.Default()).With().Timestamp().Logger()
+//
+// follows later SetDefault calls without being rebuilt or raced.
+func Default() io.Writer {
+	return defaultProxy{}
+}
+
+type defaultProxy struct{}
+
+func (defaultProxy) Write(p []byte) (int, error) {
+	return currentDefault().Write(p)
+}
+
+func (defaultProxy) WriteLevel(l Level, p []byte) (int, error) {
+	w := currentDefault()
+	if lw, ok := w.(LevelWriter); ok {
+		return lw.WriteLevel(l, p)
+	}
+	return w.Write(p)
+}
@@ -0,0 +1,34 @@
+package consoleEx
+
+import (
+	"io"
+	"sync/atomic"
+
+	. "github.com/rs/zerolog"
+)
+
+// DiagComponent is the component field of diagnostics events.
+var DiagComponent = "consoleEx"
+
+var diagWriter atomic.Value
+
+// SetDiagnostics sets where consoleEx reports changes in its own state,
+// such as a Watchdog giving up on a sink, as JSON events. They are
+// discarded until it is called. It must not be a writer the reports are
+// about.
+func SetDiagnostics(w io.Writer) {
+	if w == nil {
+		w = io.Discard
+	}
+	diagWriter.Store(writerHolder{w})
+}
+
+// diag starts a diagnostics event at level, nil if nobody listens.
+func diag(level Level) *Event {
+	h, ok := diagWriter.Load().(writerHolder)
+	if !ok || h.w == io.Discard {
+		return nil
+	}
+	l := New(h.w).With().Timestamp().Str("component", DiagComponent).Logger()
+	return l.WithLevel(level)
+}
@@ -0,0 +1,45 @@
+package consoleEx
+
+import "sync"
+
+// FieldDiff remembers the fields of the last event of each component so
+// ConsoleWriterEx can dim fields that did not change and highlight the ones
+// that did, which makes state transitions in loops such as reconcilers
+// stand out. Set it as ConsoleWriterEx.Diff.
+type FieldDiff struct {
+	// ComponentField groups events; events without it share one group.
+	ComponentField string
+
+	mu   sync.Mutex
+	last map[string]map[string]string
+}
+
+// NewFieldDiff groups events by the "component" field.
+func NewFieldDiff() *FieldDiff {
+	return &FieldDiff{ComponentField: "component"}
+}
+
+// observe records event and reports which of its fields differ from the
+// previous event of the same component, leaving out the component field
+// itself. Fields of a component's first event count as changed.
+func (d *FieldDiff) observe(event map[string]interface{}, fields []string) map[string]bool {
+	component := valueString(event[d.ComponentField])
+	cur := make(map[string]string, len(fields))
+	for _, f := range fields {
+		cur[f] = valueString(event[f])
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.last == nil {
+		d.last = make(map[string]map[string]string)
+	}
+	prev, seen := d.last[component]
+	d.last[component] = cur
+	changed := make(map[string]bool, len(fields))
+	for f, v := range cur {
+		old, ok := prev[f]
+		changed[f] = !seen || !ok || old != v
+	}
+	delete(changed, d.ComponentField)
+	return changed
+}
@@ -0,0 +1,482 @@
+package consoleEx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SpoolBatch describes one sealed segment waiting in an EdgeSpool.
+type SpoolBatch struct {
+	Name      string    `json:"name"`
+	Codec     string    `json:"codec,omitempty"`
+	Events    int       `json:"events"`
+	Bytes     int64     `json:"bytes"`
+	First     time.Time `json:"first"`
+	Last      time.Time `json:"last"`
+	Sealed    time.Time `json:"sealed"`
+	Attempts  int       `json:"attempts,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+	// Recovered marks segments found on disk without a manifest entry,
+	// usually left open by a crash; their tail may be truncated.
+	Recovered bool `json:"recovered,omitempty"`
+}
+
+// Uploader ships one sealed batch. r yields the segment as stored, that is
+// compressed with the codec named in b.Codec.
+type Uploader func(ctx context.Context, b SpoolBatch, r io.Reader) error
+
+// UploadWindow is a daily time range, as offsets from local midnight,
+// during which an EdgeSpool may upload. End before Start wraps midnight.
+type UploadWindow struct {
+	Start, End time.Duration
+}
+
+func (w UploadWindow) contains(t time.Time) bool {
+	y, m, d := t.Date()
+	off := t.Sub(time.Date(y, m, d, 0, 0, 0, 0, t.Location()))
+	if w.End < w.Start {
+		return off >= w.Start || off < w.End
+	}
+	return off >= w.Start && off < w.End
+}
+
+// ParseUploadWindows parses a list such as "01:00-05:00,22:30-23:30".
+func ParseUploadWindows(s string) ([]UploadWindow, error) {
+	var windows []UploadWindow
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, fmt.Errorf("consoleEx: invalid upload window %q", part)
+		}
+		start, err := clockOffset(from)
+		if err != nil {
+			return nil, err
+		}
+		end, err := clockOffset(to)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, UploadWindow{start, end})
+	}
+	return windows, nil
+}
+
+func clockOffset(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("consoleEx: invalid clock time %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+const spoolManifest = "manifest.json"
+
+// EdgeSpool is an offline-first sink for devices with intermittent or
+// metered links. Events are appended to a compressed segment in Dir, which
+// is sealed once it holds SegmentBytes of input or is SegmentAge old. Sealed
+// segments are listed in Dir/manifest.json and handed to Upload oldest
+// first, only inside Windows (always, if empty). Uploaded segments are
+// deleted; failed ones stay for the next round. If MaxBytes is set, the
+// oldest sealed segments are dropped to keep the spool under it.
+//
+// The fields are set by NewEdgeSpool and its options and must not change
+// afterwards.
+type EdgeSpool struct {
+	Dir          string
+	Codec        Codec
+	SegmentBytes int64
+	SegmentAge   time.Duration
+	Windows      []UploadWindow
+	MaxBytes     int64
+	Upload       Uploader
+	// CheckInterval is how often segments are sealed by age and uploads
+	// attempted.
+	CheckInterval time.Duration
+
+	ctx      context.Context
+	mu       sync.Mutex
+	f        *os.File
+	zw       io.WriteCloser
+	cur      SpoolBatch
+	in       int64
+	pending  []SpoolBatch
+	dropped  int
+	uploadMu sync.Mutex
+	done     chan struct{}
+	closed   bool
+}
+
+// SpoolOption configures an EdgeSpool before it starts.
+type SpoolOption func(*EdgeSpool)
+
+// NewEdgeSpool opens or creates the spool in dir, picking up batches left
+// by an earlier run, and starts its background loop, which stops when ctx
+// is done or Close is called. opts are applied to the defaults before
+// that:
+//
+//	s, err := consoleEx.NewEdgeSpool(ctx, "spool", upload, func(s *consoleEx.EdgeSpool) {
+//		s.Windows, _ = consoleEx.ParseUploadWindows("01:00-05:00")
+//		s.MaxBytes = 256 << 20
+//	})
+func NewEdgeSpool(ctx context.Context, dir string, upload Uploader, opts ...SpoolOption) (*EdgeSpool, error) {
+	s := &EdgeSpool{
+		Dir:           dir,
+		Codec:         GzipCodec{Level: gzip.BestCompression},
+		SegmentBytes:  4 << 20,
+		SegmentAge:    time.Minute,
+		Upload:        upload,
+		CheckInterval: 10 * time.Second,
+		ctx:           ctx,
+		done:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.CheckInterval <= 0 {
+		return nil, fmt.Errorf("consoleEx: spool check interval must be positive")
+	}
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	go s.loop()
+	return s, nil
+}
+
+func (s *EdgeSpool) load() error {
+	b, err := os.ReadFile(filepath.Join(s.Dir, spoolManifest))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &s.pending); err != nil {
+			return fmt.Errorf("consoleEx: spool manifest: %w", err)
+		}
+	}
+	known := make(map[string]bool)
+	kept := s.pending[:0]
+	for _, p := range s.pending {
+		if _, err := os.Stat(filepath.Join(s.Dir, p.Name)); err == nil {
+			kept = append(kept, p)
+			known[p.Name] = true
+		}
+	}
+	s.pending = kept
+	names, err := filepath.Glob(filepath.Join(s.Dir, "seg-*"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		base := filepath.Base(name)
+		if known[base] {
+			continue
+		}
+		fi, err := os.Stat(name)
+		if err != nil {
+			continue
+		}
+		codec := ""
+		if ext := filepath.Ext(base); ext != ".ndjson" {
+			codec = strings.TrimPrefix(ext, ".")
+		}
+		s.pending = append(s.pending, SpoolBatch{
+			Name: base, Codec: codec, Bytes: fi.Size(),
+			First: fi.ModTime(), Last: fi.ModTime(), Sealed: fi.ModTime(), Recovered: true,
+		})
+	}
+	return s.saveManifest()
+}
+
+// saveManifest rewrites the manifest atomically. Callers hold s.mu.
+func (s *EdgeSpool) saveManifest() error {
+	b, err := json.MarshalIndent(s.pending, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(s.Dir, "."+spoolManifest+".tmp")
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.Dir, spoolManifest))
+}
+
+func (s *EdgeSpool) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, errSinkClosed
+	}
+	now := time.Now()
+	if s.f == nil {
+		if err := s.openSegment(now); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := s.zw.Write(p); err != nil {
+		return 0, err
+	}
+	s.cur.Events++
+	s.cur.Last = now
+	s.in += int64(len(p))
+	if s.SegmentBytes > 0 && s.in >= s.SegmentBytes {
+		if err := s.seal(now); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+func (s *EdgeSpool) openSegment(now time.Time) error {
+	name := fmt.Sprintf("seg-%d.ndjson", now.UnixNano())
+	codec := ""
+	if s.Codec != nil {
+		codec = s.Codec.Name()
+		name += "." + codec
+	}
+	f, err := os.OpenFile(filepath.Join(s.Dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	s.f = f
+	s.zw = nopCloser{f}
+	if s.Codec != nil {
+		s.zw = s.Codec.NewWriter(f)
+	}
+	s.cur = SpoolBatch{Name: name, Codec: codec, First: now}
+	s.in = 0
+	return nil
+}
+
+// seal closes the open segment and adds it to the manifest. Callers hold
+// s.mu.
+func (s *EdgeSpool) seal(now time.Time) error {
+	if s.f == nil {
+		return nil
+	}
+	err := s.zw.Close()
+	if cerr := s.f.Close(); err == nil {
+		err = cerr
+	}
+	s.f, s.zw = nil, nil
+	if fi, serr := os.Stat(filepath.Join(s.Dir, s.cur.Name)); serr == nil {
+		s.cur.Bytes = fi.Size()
+	}
+	s.cur.Sealed = now
+	s.pending = append(s.pending, s.cur)
+	s.enforceLimit()
+	if merr := s.saveManifest(); err == nil {
+		err = merr
+	}
+	return err
+}
+
+func (s *EdgeSpool) enforceLimit() {
+	if s.MaxBytes <= 0 {
+		return
+	}
+	var total int64
+	for _, p := range s.pending {
+		total += p.Bytes
+	}
+	for total > s.MaxBytes && len(s.pending) > 1 {
+		os.Remove(filepath.Join(s.Dir, s.pending[0].Name))
+		total -= s.pending[0].Bytes
+		s.pending = s.pending[1:]
+		s.dropped++
+	}
+}
+
+// Pressure reports the spooled bytes against MaxBytes and the batches
+// dropped to honor it.
+func (s *EdgeSpool) Pressure() Pressure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var total int64
+	for _, p := range s.pending {
+		total += p.Bytes
+	}
+	return newPressure(int(total), int(s.MaxBytes), uint64(s.dropped))
+}
+
+// Seal closes the open segment so it becomes eligible for upload.
+func (s *EdgeSpool) Seal() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seal(time.Now())
+}
+
+// Pending returns the sealed batches waiting for upload, oldest first.
+func (s *EdgeSpool) Pending() []SpoolBatch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SpoolBatch(nil), s.pending...)
+}
+
+// Dropped reports how many batches were discarded to honor MaxBytes.
+func (s *EdgeSpool) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// ServeHTTP responds with the pending batches as a JSON array.
+func (s *EdgeSpool) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Pending())
+}
+
+// InWindow reports whether uploads are allowed at t.
+func (s *EdgeSpool) InWindow(t time.Time) bool {
+	if len(s.Windows) == 0 {
+		return true
+	}
+	for _, w := range s.Windows {
+		if w.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *EdgeSpool) loop() {
+	t := time.NewTicker(s.CheckInterval)
+	defer t.Stop()
+	for {
+		select {
+		case now := <-t.C:
+			s.mu.Lock()
+			if s.f != nil && s.SegmentAge > 0 && now.Sub(s.cur.First) >= s.SegmentAge {
+				s.seal(now)
+			}
+			s.mu.Unlock()
+			if s.InWindow(now) {
+				s.UploadNow(s.ctx)
+			}
+		case <-s.ctx.Done():
+			s.Close()
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// UploadNow uploads pending batches oldest first regardless of Windows,
+// stopping at the first failure.
+func (s *EdgeSpool) UploadNow(ctx context.Context) error {
+	if s.Upload == nil {
+		return nil
+	}
+	s.uploadMu.Lock()
+	defer s.uploadMu.Unlock()
+	for {
+		s.mu.Lock()
+		if len(s.pending) == 0 {
+			s.mu.Unlock()
+			return nil
+		}
+		b := s.pending[0]
+		s.mu.Unlock()
+		err := s.uploadOne(ctx, b)
+		s.mu.Lock()
+		i := s.indexOf(b.Name)
+		if err != nil {
+			if i >= 0 {
+				s.pending[i].Attempts++
+				s.pending[i].LastError = err.Error()
+				s.saveManifest()
+			}
+			s.mu.Unlock()
+			return err
+		}
+		os.Remove(filepath.Join(s.Dir, b.Name))
+		if i >= 0 {
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+			s.saveManifest()
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *EdgeSpool) uploadOne(ctx context.Context, b SpoolBatch) error {
+	f, err := os.Open(filepath.Join(s.Dir, b.Name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.Upload(ctx, b, f)
+}
+
+func (s *EdgeSpool) indexOf(name string) int {
+	for i, p := range s.pending {
+		if p.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Close seals the open segment and stops the background loop. Pending
+// batches stay on disk for the next run.
+func (s *EdgeSpool) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	err := s.seal(time.Now())
+	close(s.done)
+	s.mu.Unlock()
+	return err
+}
+
+// HTTPUploader posts each batch to url as newline delimited JSON, with the
+// batch codec as Content-Encoding. Any non 2xx answer is an error.
+func HTTPUploader(client *http.Client, url string) Uploader {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context, b SpoolBatch, r io.Reader) error {
+		body, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		if b.Codec != "" {
+			req.Header.Set("Content-Encoding", b.Codec)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("consoleEx: %s: %s", url, resp.Status)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,186 @@
+package consoleEx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Encoder serializes decoded events for machine sinks that prefer a binary
+// format over JSON. Schema based formats such as protobuf or Avro can be
+// added with RegisterEncoder; msgpack is built in.
+type Encoder interface {
+	Name() string
+	ContentType() string
+	// Encode appends the encoding of event to dst. Encoded events must be
+	// self-delimiting so they can be concatenated into a stream.
+	Encode(dst []byte, event map[string]interface{}) ([]byte, error)
+}
+
+var encoderRegistry = struct {
+	sync.RWMutex
+	m map[string]Encoder
+}{m: map[string]Encoder{"msgpack": MsgpackEncoder{}}}
+
+// RegisterEncoder makes e available by name, including as a sink format
+// (?format=msgpack).
+func RegisterEncoder(e Encoder) {
+	encoderRegistry.Lock()
+	defer encoderRegistry.Unlock()
+	encoderRegistry.m[e.Name()] = e
+}
+
+// LookupEncoder returns the encoder registered as name.
+func LookupEncoder(name string) (Encoder, bool) {
+	encoderRegistry.RLock()
+	defer encoderRegistry.RUnlock()
+	e, ok := encoderRegistry.m[name]
+	return e, ok
+}
+
+// EncodeWriter re-encodes each JSON event with Encoder before writing it to
+// Next in a single call.
+type EncodeWriter struct {
+	Next    io.Writer
+	Encoder Encoder
+}
+
+func (w EncodeWriter) Write(p []byte) (int, error) {
+	event, err := DecodeEvent(p)
+	if err != nil {
+		return 0, err
+	}
+	b, err := w.Encoder.Encode(nil, event)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.Next.Write(b); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Encode returns a Decorator re-encoding events with e.
+func Encode(e Encoder) Decorator {
+	return func(next io.Writer) io.Writer {
+		return EncodeWriter{Next: next, Encoder: e}
+	}
+}
+
+// MsgpackEncoder encodes events as MessagePack maps with sorted keys.
+// Numbers become integers when they fit in an int64 and floats otherwise.
+type MsgpackEncoder struct{}
+
+func (MsgpackEncoder) Name() string        { return "msgpack" }
+func (MsgpackEncoder) ContentType() string { return "application/msgpack" }
+
+func (MsgpackEncoder) Encode(dst []byte, event map[string]interface{}) ([]byte, error) {
+	return appendMsgpack(dst, event)
+}
+
+func appendMsgpack(b []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if v {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case string:
+		return appendMsgpackString(b, v), nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return appendMsgpackInt(b, i), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return b, err
+		}
+		return appendMsgpackFloat(b, f), nil
+	case float64:
+		return appendMsgpackFloat(b, v), nil
+	case int:
+		return appendMsgpackInt(b, int64(v)), nil
+	case int64:
+		return appendMsgpackInt(b, v), nil
+	case []interface{}:
+		b = appendMsgpackHeader(b, len(v), 0x90, 0xdc)
+		var err error
+		for _, e := range v {
+			if b, err = appendMsgpack(b, e); err != nil {
+				return b, err
+			}
+		}
+		return b, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b = appendMsgpackHeader(b, len(v), 0x80, 0xde)
+		var err error
+		for _, k := range keys {
+			b = appendMsgpackString(b, k)
+			if b, err = appendMsgpack(b, v[k]); err != nil {
+				return b, err
+			}
+		}
+		return b, nil
+	}
+	return b, fmt.Errorf("consoleEx: msgpack: unsupported type %T", v)
+}
+
+// appendMsgpackHeader writes an array or map header: fix for fewer than 16
+// elements, then the 16 and 32 bit forms starting at wide.
+func appendMsgpackHeader(b []byte, n int, fix, wide byte) []byte {
+	switch {
+	case n < 16:
+		return append(b, fix|byte(n))
+	case n <= 0xffff:
+		return append(b, wide, byte(n>>8), byte(n))
+	}
+	return append(b, wide+1, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+func appendMsgpackString(b []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= 0xff:
+		b = append(b, 0xd9, byte(n))
+	case n <= 0xffff:
+		b = append(b, 0xda, byte(n>>8), byte(n))
+	default:
+		b = append(b, 0xdb, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(b, s...)
+}
+
+func appendMsgpackInt(b []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i < 128:
+		return append(b, byte(i))
+	case i < 0 && i >= -32:
+		return append(b, byte(i))
+	case i >= -128 && i <= 127:
+		return append(b, 0xd0, byte(i))
+	case i >= -32768 && i <= 32767:
+		return append(b, 0xd1, byte(i>>8), byte(i))
+	case i >= -1<<31 && i <= 1<<31-1:
+		return append(b, 0xd2, byte(i>>24), byte(i>>16), byte(i>>8), byte(i))
+	}
+	return append(b, 0xd3, byte(i>>56), byte(i>>48), byte(i>>40), byte(i>>32),
+		byte(i>>24), byte(i>>16), byte(i>>8), byte(i))
+}
+
+func appendMsgpackFloat(b []byte, f float64) []byte {
+	u := math.Float64bits(f)
+	return append(b, 0xcb, byte(u>>56), byte(u>>48), byte(u>>40), byte(u>>32),
+		byte(u>>24), byte(u>>16), byte(u>>8), byte(u))
+}
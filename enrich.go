@@ -0,0 +1,139 @@
+package consoleEx
+
+import (
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Lookup resolves the value of a field to extra fields, such as a user ID
+// to a username or an IP address to geo and ASN data from a local mmdb
+// reader. Lookups should give up when ctx is done.
+type Lookup func(ctx context.Context, value string) (map[string]interface{}, error)
+
+// MapLookup returns a Lookup adding field with the value m maps to, for
+// small static tables.
+func MapLookup(field string, m map[string]string) Lookup {
+	return func(ctx context.Context, value string) (map[string]interface{}, error) {
+		if v, ok := m[value]; ok {
+			return map[string]interface{}{field: v}, nil
+		}
+		return nil, nil
+	}
+}
+
+// Enricher adds the fields Lookup returns for the value of Field. Results
+// are cached for TTL, failures for NegativeTTL so a broken backend is not
+// hit for every event. A failed or slow lookup never fails the write; the
+// event just goes out without the extra fields.
+type Enricher struct {
+	Field       string
+	Lookup      Lookup
+	TTL         time.Duration
+	NegativeTTL time.Duration
+	Timeout     time.Duration
+	MaxEntries  int
+
+	mu    sync.Mutex
+	cache map[string]enrichEntry
+}
+
+type enrichEntry struct {
+	fields  map[string]interface{}
+	expires time.Time
+}
+
+// NewEnricher caches results for ten minutes and failures for ten seconds,
+// bounding lookups to 100ms and the cache to 10000 values.
+func NewEnricher(field string, lookup Lookup) *Enricher {
+	return &Enricher{
+		Field:       field,
+		Lookup:      lookup,
+		TTL:         10 * time.Minute,
+		NegativeTTL: 10 * time.Second,
+		Timeout:     100 * time.Millisecond,
+		MaxEntries:  10000,
+	}
+}
+
+func (e *Enricher) resolve(value string) map[string]interface{} {
+	now := time.Now()
+	e.mu.Lock()
+	if c, ok := e.cache[value]; ok && now.Before(c.expires) {
+		e.mu.Unlock()
+		return c.fields
+	}
+	e.mu.Unlock()
+	ctx := context.Background()
+	if e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+	fields, err := e.Lookup(ctx, value)
+	ttl := e.TTL
+	if err != nil {
+		fields, ttl = nil, e.NegativeTTL
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.cache == nil {
+		e.cache = make(map[string]enrichEntry)
+	}
+	if e.MaxEntries > 0 && len(e.cache) >= e.MaxEntries {
+		for k, c := range e.cache {
+			if now.After(c.expires) || len(e.cache) >= e.MaxEntries {
+				delete(e.cache, k)
+			}
+		}
+	}
+	e.cache[value] = enrichEntry{fields, now.Add(ttl)}
+	return fields
+}
+
+// EnrichWriter applies Enrichers to each event before writing it to Next.
+// Added fields never replace fields already in the event.
+type EnrichWriter struct {
+	Next      io.Writer
+	Enrichers []*Enricher
+}
+
+func (w EnrichWriter) Write(p []byte) (int, error) {
+	event, err := DecodeEvent(p)
+	if err != nil {
+		return w.Next.Write(p)
+	}
+	q := p
+	for _, e := range w.Enrichers {
+		v, ok := event[e.Field]
+		if !ok {
+			continue
+		}
+		fields := e.resolve(valueString(v))
+		names := make([]string, 0, len(fields))
+		for k := range fields {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			if _, exists := event[k]; exists {
+				continue
+			}
+			event[k] = fields[k]
+			q = injectField(q, k, fields[k])
+		}
+	}
+	if _, err := w.Next.Write(q); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Enrich returns a Decorator applying enrichers, see EnrichWriter.
+func Enrich(enrichers ...*Enricher) Decorator {
+	return func(next io.Writer) io.Writer {
+		return EnrichWriter{Next: next, Enrichers: enrichers}
+	}
+}
@@ -0,0 +1,140 @@
+package consoleEx
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	. "github.com/rs/zerolog"
+)
+
+// errorChain unwraps the error field into its causes, outermost first. It
+// understands strings joined with ": ", as fmt.Errorf("...: %w") leaves
+// them, and nested objects such as {"message": "...", "cause": {...}}.
+func errorChain(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Split(v, ": ")
+	case map[string]interface{}:
+		var chain []string
+		for depth := 0; v != nil && depth < 32; depth++ {
+			var next interface{}
+			text := ""
+			for _, k := range []string{"message", "msg", ErrorFieldName} {
+				if s, ok := v[k]; ok {
+					text = valueString(s)
+					break
+				}
+			}
+			for _, k := range []string{"cause", "wrapped", "inner"} {
+				if c, ok := v[k]; ok {
+					next = c
+					break
+				}
+			}
+			if text != "" {
+				chain = append(chain, text)
+			}
+			switch c := next.(type) {
+			case map[string]interface{}:
+				v = c
+			case nil:
+				v = nil
+			default:
+				chain = append(chain, errorChain(c)...)
+				v = nil
+			}
+		}
+		return chain
+	}
+	return nil
+}
+
+// takeErrorChain removes the error field from fields when ErrorChain is
+// set and it holds more than one cause.
+func (w ConsoleWriterEx) takeErrorChain(event map[string]interface{}, fields []string) ([]string, []string) {
+	if !w.ErrorChain || !containsString(fields, ErrorFieldName) {
+		return fields, nil
+	}
+	chain := errorChain(event[ErrorFieldName])
+	if len(chain) < 2 {
+		return fields, nil
+	}
+	return withoutField(fields, ErrorFieldName), chain
+}
+
+// writeErrorChain renders one cause per line, the root cause in red.
+func (w ConsoleWriterEx) writeErrorChain(buf *bytes.Buffer, chain []string) {
+	for i, cause := range chain {
+		switch {
+		case i == 0:
+			fmt.Fprintf(buf, "\n    %s %s", colorize(ErrorFieldName+":", cCyan, w.colors()), cause)
+		case i == len(chain)-1:
+			fmt.Fprintf(buf, "\n      caused by: %s", colorize(cause, cRed, w.colors()))
+		default:
+			fmt.Fprintf(buf, "\n      caused by: %s", cause)
+		}
+	}
+}
+
+// MultiErrorsFieldName is the field zerolog's Errs is commonly given,
+// rendered like ErrorFieldName by MultiErrors.
+var MultiErrorsFieldName = "errors"
+
+type errorList struct {
+	field string
+	errs  []string
+}
+
+// multiErrors returns the sub-errors of an array, or of a string joined
+// with newlines as errors.Join leaves it, if there are several.
+func multiErrors(v interface{}) []string {
+	var errs []string
+	switch v := v.(type) {
+	case []interface{}:
+		for _, e := range v {
+			if e != nil {
+				errs = append(errs, valueString(e))
+			}
+		}
+	case string:
+		for _, e := range strings.Split(v, "\n") {
+			if e != "" {
+				errs = append(errs, e)
+			}
+		}
+		if len(errs) < 2 {
+			return nil
+		}
+	}
+	return errs
+}
+
+// takeMultiErrors removes the multi-error fields from fields when
+// MultiErrors is set.
+func (w ConsoleWriterEx) takeMultiErrors(event map[string]interface{}, fields []string) ([]string, []errorList) {
+	if !w.MultiErrors {
+		return fields, nil
+	}
+	var lists []errorList
+	for _, name := range []string{ErrorFieldName, MultiErrorsFieldName} {
+		if !containsString(fields, name) {
+			continue
+		}
+		if errs := multiErrors(event[name]); len(errs) > 0 {
+			lists = append(lists, errorList{name, errs})
+			fields = withoutField(fields, name)
+		}
+	}
+	return fields, lists
+}
+
+// writeMultiErrors renders each list as bullets under its field name.
+func (w ConsoleWriterEx) writeMultiErrors(buf *bytes.Buffer, lists []errorList) {
+	for _, l := range lists {
+		fmt.Fprintf(buf, "\n    %s", colorize(l.field+":", cCyan, w.colors()))
+		for _, e := range l.errs {
+			fmt.Fprintf(buf, "\n      - %s", strings.Replace(e, "\n", "\n        ", -1))
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package consoleEx
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+// ExecSink runs a command and writes each event to its stdin as one line
+// of NDJSON, so destinations can be added in any language without forking
+// the package. Each line the command prints on stdout is a JSON object
+// such as {"level":"error","message":"quota exceeded"}, reported through
+// SetDiagnostics with a sink field holding Path; other lines are reported
+// as info messages. The command's stderr is the process's.
+//
+// When the command exits it is started again on the next write, at most
+// once per RestartDelay; writes in between fail. On Close its stdin is
+// closed, the signal to flush and exit, and it is killed if it has not
+// exited after CloseTimeout.
+type ExecSink struct {
+	Path         string
+	Args         []string
+	RestartDelay time.Duration
+	CloseTimeout time.Duration
+
+	mu      sync.Mutex
+	stdin   io.WriteCloser
+	cmd     *exec.Cmd
+	exited  chan struct{}
+	started time.Time
+	err     error
+	line    []byte
+	closed  bool
+	done    chan struct{}
+}
+
+func NewExecSink(path string, args ...string) *ExecSink {
+	return NewExecSinkContext(context.Background(), path, args...)
+}
+
+// NewExecSinkContext returns an ExecSink that is closed when ctx is done.
+func NewExecSinkContext(ctx context.Context, path string, args ...string) *ExecSink {
+	s := &ExecSink{Path: path, Args: args, RestartDelay: time.Second, CloseTimeout: 5 * time.Second, done: make(chan struct{})}
+	if ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				s.Close()
+			case <-s.done:
+			}
+		}()
+	}
+	return s
+}
+
+func (s *ExecSink) running() bool {
+	if s.stdin == nil {
+		return false
+	}
+	select {
+	case <-s.exited:
+		s.stdin.Close()
+		s.stdin = nil
+		return false
+	default:
+		return true
+	}
+}
+
+func (s *ExecSink) start() error {
+	if !s.started.IsZero() && time.Since(s.started) < s.RestartDelay {
+		return s.err
+	}
+	s.started = time.Now()
+	cmd := exec.Command(s.Path, s.Args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		s.err = err
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		stdin.Close()
+		s.err = err
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		s.err = err
+		return err
+	}
+	exited := make(chan struct{})
+	go func() {
+		s.report(stdout)
+		if err := cmd.Wait(); err != nil {
+			s.mu.Lock()
+			s.err = err
+			s.mu.Unlock()
+		}
+		close(exited)
+	}()
+	s.stdin, s.cmd, s.exited, s.err = stdin, cmd, exited, errSinkClosed
+	return nil
+}
+
+func (s *ExecSink) report(stdout io.Reader) {
+	sc := bufio.NewScanner(stdout)
+	for sc.Scan() {
+		level, msg := InfoLevel, sc.Text()
+		var reply struct {
+			Level   string `json:"level"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(sc.Bytes(), &reply) == nil {
+			msg = reply.Message
+			if l, err := ParseLevel(reply.Level); err == nil && reply.Level != "" {
+				level = l
+			}
+		}
+		if e := diag(level); e != nil {
+			e.Str("sink", s.Path).Msg(msg)
+		}
+	}
+}
+
+func (s *ExecSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, errSinkClosed
+	}
+	if !s.running() {
+		if err := s.start(); err != nil {
+			return 0, err
+		}
+	}
+	s.line = append(append(s.line[:0], trimNewline(p)...), '\n')
+	if _, err := s.stdin.Write(s.line); err != nil {
+		s.stdin.Close()
+		s.stdin = nil
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the command's stdin and waits for it to exit.
+func (s *ExecSink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	stdin, cmd, exited := s.stdin, s.cmd, s.exited
+	s.stdin = nil
+	s.mu.Unlock()
+	if stdin == nil {
+		return nil
+	}
+	stdin.Close()
+	select {
+	case <-exited:
+	case <-time.After(s.CloseTimeout):
+		cmd.Process.Kill()
+		<-exited
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == errSinkClosed {
+		return nil
+	}
+	return s.err
+}
@@ -0,0 +1,465 @@
+package consoleEx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	. "github.com/rs/zerolog"
+)
+
+// CompileExpr compiles a filter expression such as
+//
+//	level >= "warn" && fields.component != "health"
+//
+// into a Predicate, so filters, redaction conditions and routing rules can
+// be kept as strings in configuration and evaluated per event without
+// being parsed again. Operands are field names, optionally prefixed with
+// "fields." and dotted into nested objects, quoted strings, numbers, true,
+// false and null. Operators are == (or =), !=, <, <=, >, >=, =~ against a
+// regular expression string, &&, ||, ! and parentheses; has(field),
+// contains(s, sub) and startsWith(s, prefix) are built in. The level field
+// compares by severity, numbers numerically and everything else as
+// strings. A missing field equals null.
+func CompileExpr(src string) (Predicate, error) {
+	toks, err := lexExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{src: src, toks: toks}
+	pred, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != 0 {
+		return nil, p.errorf(t, "unexpected %q", t.text)
+	}
+	return pred, nil
+}
+
+// MustCompileExpr is CompileExpr panicking on errors, for expressions
+// known to be valid.
+func MustCompileExpr(src string) Predicate {
+	p, err := CompileExpr(src)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+type exprToken struct {
+	kind byte // 'i'dent, 's'tring, 'n'umber, 'o'perator, 0 at the end
+	text string
+	pos  int
+}
+
+func lexExpr(s string) ([]exprToken, error) {
+	var toks []exprToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(s) && s[j] != c; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+					switch s[j] {
+					case 'n':
+						b.WriteByte('\n')
+					case 't':
+						b.WriteByte('\t')
+					default:
+						b.WriteByte(s[j])
+					}
+					continue
+				}
+				b.WriteByte(s[j])
+			}
+			if j == len(s) {
+				return nil, fmt.Errorf("invalid expression %q: unterminated string at %d", s, i)
+			}
+			toks = append(toks, exprToken{'s', b.String(), i})
+			i = j + 1
+		case c >= '0' && c <= '9' || c == '-' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			j := i + 1
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.' || s[j] == 'e' || s[j] == 'E' ||
+				(s[j] == '+' || s[j] == '-') && (s[j-1] == 'e' || s[j-1] == 'E')) {
+				j++
+			}
+			if _, err := strconv.ParseFloat(s[i:j], 64); err != nil {
+				return nil, fmt.Errorf("invalid expression %q: bad number %q at %d", s, s[i:j], i)
+			}
+			toks = append(toks, exprToken{'n', s[i:j], i})
+			i = j
+		case isIdentByte(c) && c != '-' && c != '.':
+			j := i + 1
+			for j < len(s) && isIdentByte(s[j]) {
+				j++
+			}
+			toks = append(toks, exprToken{'i', s[i:j], i})
+			i = j
+		default:
+			op := ""
+			for _, o := range []string{"&&", "||", "==", "!=", ">=", "<=", "=~", "!", "<", ">", "=", "(", ")", ","} {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("invalid expression %q: unexpected %q at %d", s, c, i)
+			}
+			toks = append(toks, exprToken{'o', op, i})
+			i += len(op)
+		}
+	}
+	return toks, nil
+}
+
+func isIdentByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '-'
+}
+
+type exprParser struct {
+	src  string
+	toks []exprToken
+	pos  int
+}
+
+// exprValue evaluates an operand; ok is false for missing fields.
+type exprValue struct {
+	get   func(event map[string]interface{}) (v interface{}, ok bool)
+	level bool
+}
+
+func (p *exprParser) peek() exprToken {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return exprToken{pos: len(p.src)}
+}
+
+func (p *exprParser) next() exprToken {
+	t := p.peek()
+	if t.kind != 0 {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) accept(op string) bool {
+	if t := p.peek(); t.kind == 'o' && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) expect(op string) error {
+	if !p.accept(op) {
+		t := p.peek()
+		return p.errorf(t, "expected %q", op)
+	}
+	return nil
+}
+
+func (p *exprParser) errorf(t exprToken, format string, args ...interface{}) error {
+	return fmt.Errorf("invalid expression %q: %s at %d", p.src, fmt.Sprintf(format, args...), t.pos)
+}
+
+func (p *exprParser) or() (Predicate, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(event map[string]interface{}) bool { return l(event) || right(event) }
+	}
+	return left, nil
+}
+
+func (p *exprParser) and() (Predicate, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(event map[string]interface{}) bool { return l(event) && right(event) }
+	}
+	return left, nil
+}
+
+func (p *exprParser) unary() (Predicate, error) {
+	if p.accept("!") {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(event map[string]interface{}) bool { return !x(event) }, nil
+	}
+	if p.accept("(") {
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return x, p.expect(")")
+	}
+	return p.comparison()
+}
+
+func (p *exprParser) comparison() (Predicate, error) {
+	left, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind != 'o' {
+		return truthy(left), nil
+	}
+	op := t.text
+	switch op {
+	case "=~":
+		p.next()
+		pat := p.next()
+		if pat.kind != 's' {
+			return nil, p.errorf(pat, "=~ needs a string")
+		}
+		re, err := regexp.Compile(pat.text)
+		if err != nil {
+			return nil, p.errorf(pat, "%v", err)
+		}
+		return func(event map[string]interface{}) bool {
+			v, ok := left.get(event)
+			return ok && v != nil && re.MatchString(exprString(v))
+		}, nil
+	case "==", "=", "!=", "<", "<=", ">", ">=":
+		p.next()
+	default:
+		return truthy(left), nil
+	}
+	if op == "==" {
+		op = "="
+	}
+	right, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	levels := left.level || right.level
+	return func(event map[string]interface{}) bool {
+		a, _ := left.get(event)
+		b, _ := right.get(event)
+		if a == nil || b == nil {
+			switch op {
+			case "=":
+				return a == nil && b == nil
+			case "!=":
+				return a != nil || b != nil
+			}
+			return false
+		}
+		return compare(op, exprCompare(a, b, levels))
+	}, nil
+}
+
+func (p *exprParser) value() (exprValue, error) {
+	t := p.next()
+	switch t.kind {
+	case 's':
+		s := t.text
+		return exprValue{get: func(map[string]interface{}) (interface{}, bool) { return s, true }}, nil
+	case 'n':
+		n := json.Number(t.text)
+		return exprValue{get: func(map[string]interface{}) (interface{}, bool) { return n, true }}, nil
+	case 'i':
+	default:
+		return exprValue{}, p.errorf(t, "expected a value")
+	}
+	switch t.text {
+	case "true", "false":
+		b := t.text == "true"
+		return exprValue{get: func(map[string]interface{}) (interface{}, bool) { return b, true }}, nil
+	case "null":
+		return exprValue{get: func(map[string]interface{}) (interface{}, bool) { return nil, true }}, nil
+	}
+	if p.accept("(") {
+		return p.call(t)
+	}
+	return fieldValue(strings.TrimPrefix(t.text, "fields.")), nil
+}
+
+func (p *exprParser) call(name exprToken) (exprValue, error) {
+	var args []exprValue
+	for !p.accept(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return exprValue{}, err
+			}
+		}
+		a, err := p.value()
+		if err != nil {
+			return exprValue{}, err
+		}
+		args = append(args, a)
+	}
+	var f func(a, b string) bool
+	switch name.text {
+	case "has":
+		if len(args) != 1 {
+			return exprValue{}, p.errorf(name, "has takes one field")
+		}
+		get := args[0].get
+		return exprValue{get: func(event map[string]interface{}) (interface{}, bool) {
+			_, ok := get(event)
+			return ok, true
+		}}, nil
+	case "contains":
+		f = strings.Contains
+	case "startsWith":
+		f = strings.HasPrefix
+	default:
+		return exprValue{}, p.errorf(name, "unknown function %s", name.text)
+	}
+	if len(args) != 2 {
+		return exprValue{}, p.errorf(name, "%s takes two arguments", name.text)
+	}
+	a, b := args[0].get, args[1].get
+	return exprValue{get: func(event map[string]interface{}) (interface{}, bool) {
+		x, ok := a(event)
+		y, ok2 := b(event)
+		return ok && ok2 && x != nil && y != nil && f(exprString(x), exprString(y)), true
+	}}, nil
+}
+
+// fieldValue looks name up in the event, then, if it is dotted and not a
+// field itself, in nested objects.
+func fieldValue(name string) exprValue {
+	parts := strings.Split(name, ".")
+	return exprValue{level: name == LevelFieldName, get: func(event map[string]interface{}) (interface{}, bool) {
+		if v, ok := event[name]; ok || len(parts) == 1 {
+			return v, ok
+		}
+		var v interface{} = event
+		for _, part := range parts {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if v, ok = m[part]; !ok {
+				return nil, false
+			}
+		}
+		return v, true
+	}}
+}
+
+func truthy(x exprValue) Predicate {
+	return func(event map[string]interface{}) bool {
+		v, ok := x.get(event)
+		if !ok {
+			return false
+		}
+		switch v := v.(type) {
+		case nil:
+			return false
+		case bool:
+			return v
+		case string:
+			return v != ""
+		case json.Number:
+			f, err := v.Float64()
+			return err == nil && f != 0
+		}
+		return true
+	}
+}
+
+func exprCompare(a, b interface{}, levels bool) int {
+	if levels {
+		la, err1 := ParseLevel(exprString(a))
+		lb, err2 := ParseLevel(exprString(b))
+		if err1 == nil && err2 == nil {
+			return int(la) - int(lb)
+		}
+	}
+	if x, ok := exprNumber(a); ok {
+		if y, ok := exprNumber(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(exprString(a), exprString(b))
+}
+
+func exprNumber(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func exprString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// MatchWriter passes on to Next only the events Match accepts. Writes that
+// are not JSON events pass unchanged.
+type MatchWriter struct {
+	Next  io.Writer
+	Match Predicate
+}
+
+func (w MatchWriter) Write(p []byte) (int, error) {
+	if event, err := DecodeEvent(p); err == nil && !w.Match(event) {
+		return len(p), nil
+	}
+	return w.Next.Write(p)
+}
+
+// WriteLevel passes level on if Next is a LevelWriter.
+func (w MatchWriter) WriteLevel(level Level, p []byte) (int, error) {
+	if event, err := DecodeEvent(p); err == nil && !w.Match(event) {
+		return len(p), nil
+	}
+	if lw, ok := w.Next.(LevelWriter); ok {
+		return lw.WriteLevel(level, p)
+	}
+	return w.Next.Write(p)
+}
+
+// Where returns a Decorator dropping the events match rejects, see
+// MatchWriter.
+//
+//	consoleEx.Where(consoleEx.MustCompileExpr(`component != "health"`))
+func Where(match Predicate) Decorator {
+	return func(next io.Writer) io.Writer {
+		return MatchWriter{Next: next, Match: match}
+	}
+}
@@ -0,0 +1,141 @@
+package consoleEx
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+// FlightFieldName marks events a FlightRecorder held back and dumped.
+var FlightFieldName = "flight"
+
+// FlightRecorder holds events below Threshold in memory for Window instead
+// of writing them. When an event at Trigger or above arrives, the held
+// events sharing one of its Keys values, such as the same request_id or
+// component, are written first, marked with FlightFieldName, so the
+// debug context of a failure shows up next to it. A trigger carrying none
+// of the keys dumps everything held. Events at Threshold or above pass
+// straight through.
+//
+//	w := consoleEx.NewFlightRecorder(console, zerolog.InfoLevel)
+//	log := zerolog.New(w).Level(zerolog.DebugLevel)
+type FlightRecorder struct {
+	Next      io.Writer
+	Threshold Level
+	Trigger   Level
+	Window    time.Duration
+	MaxEvents int
+	Keys      []string
+
+	mu   sync.Mutex
+	held []flightEntry
+}
+
+type flightEntry struct {
+	at    time.Time
+	event map[string]interface{}
+	data  []byte
+}
+
+// NewFlightRecorder dumps up to three minutes and 10000 events on errors,
+// grouped by request_id and component.
+func NewFlightRecorder(next io.Writer, threshold Level) *FlightRecorder {
+	return &FlightRecorder{
+		Next:      next,
+		Threshold: threshold,
+		Trigger:   ErrorLevel,
+		Window:    3 * time.Minute,
+		MaxEvents: 10000,
+		Keys:      []string{"request_id", "component"},
+	}
+}
+
+func (r *FlightRecorder) Write(p []byte) (int, error) {
+	event, err := DecodeEvent(p)
+	if err != nil {
+		return r.Next.Write(p)
+	}
+	level := NoLevel
+	if s, ok := event[LevelFieldName].(string); ok {
+		if l, err := ParseLevel(s); err == nil {
+			level = l
+		}
+	}
+	now := time.Now()
+	r.mu.Lock()
+	r.expire(now)
+	if level != NoLevel && level < r.Threshold {
+		r.held = append(r.held, flightEntry{now, event, append([]byte(nil), p...)})
+		if r.MaxEvents > 0 && len(r.held) > r.MaxEvents {
+			r.held = r.held[len(r.held)-r.MaxEvents:]
+		}
+		r.mu.Unlock()
+		return len(p), nil
+	}
+	var dump [][]byte
+	if level != NoLevel && level >= r.Trigger {
+		dump = r.take(event)
+	}
+	r.mu.Unlock()
+	for _, d := range dump {
+		if _, err := r.Next.Write(injectField(d, FlightFieldName, true)); err != nil {
+			return 0, err
+		}
+	}
+	return r.Next.Write(p)
+}
+
+// expire drops held events older than Window.
+func (r *FlightRecorder) expire(now time.Time) {
+	if r.Window <= 0 {
+		return
+	}
+	i := 0
+	for i < len(r.held) && now.Sub(r.held[i].at) > r.Window {
+		i++
+	}
+	r.held = r.held[i:]
+}
+
+// take removes and returns the held events related to the trigger event.
+func (r *FlightRecorder) take(trigger map[string]interface{}) [][]byte {
+	keys := make(map[string]string)
+	for _, k := range r.Keys {
+		if v, ok := trigger[k]; ok {
+			keys[k] = valueString(v)
+		}
+	}
+	var out [][]byte
+	kept := r.held[:0]
+	for _, e := range r.held {
+		if len(keys) == 0 || flightRelated(e.event, keys) {
+			out = append(out, e.data)
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	for i := len(kept); i < len(r.held); i++ {
+		r.held[i] = flightEntry{}
+	}
+	r.held = kept
+	return out
+}
+
+func flightRelated(event map[string]interface{}, keys map[string]string) bool {
+	for k, v := range keys {
+		if ev, ok := event[k]; ok && valueString(ev) == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Flight returns a Decorator holding events below threshold, see
+// FlightRecorder.
+func Flight(threshold Level) Decorator {
+	return func(next io.Writer) io.Writer {
+		return NewFlightRecorder(next, threshold)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package consoleEx
+
+import "os"
+
+func lockFile(f *os.File) error   { return nil }
+func unlockFile(f *os.File) error { return nil }
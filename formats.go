@@ -0,0 +1,200 @@
+package consoleEx
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"sync"
+
+	. "github.com/rs/zerolog"
+)
+
+// LogfmtWriter renders each JSON event as a logfmt line.
+type LogfmtWriter struct {
+	Out io.Writer
+}
+
+func (w LogfmtWriter) Write(p []byte) (int, error) {
+	event, err := DecodeEvent(p)
+	if err != nil {
+		return 0, err
+	}
+	buf := &bytes.Buffer{}
+	for _, k := range []string{TimestampFieldName, LevelFieldName, CallerFieldName, MessageFieldName} {
+		if v, ok := event[k]; ok {
+			fmt.Fprintf(buf, "%s=%s ", k, quoteValue(v))
+		}
+	}
+	for _, k := range fieldNames(event) {
+		fmt.Fprintf(buf, "%s=%s ", k, quoteValue(event[k]))
+	}
+	b := bytes.TrimRight(buf.Bytes(), " ")
+	if _, err := w.Out.Write(append(b, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// CSVWriter renders events as CSV rows of time, level, caller, message and
+// the remaining fields in logfmt. The header row is written first.
+type CSVWriter struct {
+	mu     sync.Mutex
+	w      *csv.Writer
+	header bool
+}
+
+func NewCSVWriter(out io.Writer) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(out)}
+}
+
+func (w *CSVWriter) Write(p []byte) (int, error) {
+	event, err := DecodeEvent(p)
+	if err != nil {
+		return 0, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.header {
+		w.header = true
+		w.w.Write([]string{TimestampFieldName, LevelFieldName, CallerFieldName, MessageFieldName, "fields"})
+	}
+	fields := make([]string, 0, len(event))
+	for _, k := range fieldNames(event) {
+		fields = append(fields, k+"="+quoteValue(event[k]))
+	}
+	row := []string{
+		csvValue(event[TimestampFieldName]),
+		csvValue(event[LevelFieldName]),
+		csvValue(event[CallerFieldName]),
+		csvValue(event[MessageFieldName]),
+		strings.Join(fields, " "),
+	}
+	if err := w.w.Write(row); err != nil {
+		return 0, err
+	}
+	w.w.Flush()
+	return len(p), w.w.Error()
+}
+
+func (w *CSVWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func csvValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return valueString(v)
+}
+
+const htmlHeader = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>log</title><style>
+body{background:#1e1e1e;color:#d4d4d4;font:13px monospace}
+table{border-collapse:collapse}td{padding:1px 8px;vertical-align:top;white-space:pre-wrap}
+.time{color:#808080}.field{color:#00bcbc}
+.trace,.debug{color:#bc3fbc}.info{color:#0dbc79}.warn{color:#e5e510}.error,.fatal,.panic{color:#f14c4c}
+</style></head><body><table>
+`
+
+const htmlFooter = "</table></body></html>\n"
+
+// HTMLWriter renders events as rows of a standalone HTML page colored like
+// the console. Close writes the end of the page.
+type HTMLWriter struct {
+	mu     sync.Mutex
+	out    io.Writer
+	header bool
+}
+
+func NewHTMLWriter(out io.Writer) *HTMLWriter {
+	return &HTMLWriter{out: out}
+}
+
+func (w *HTMLWriter) Write(p []byte) (int, error) {
+	event, err := DecodeEvent(p)
+	if err != nil {
+		return 0, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	buf := &bytes.Buffer{}
+	if !w.header {
+		w.header = true
+		buf.WriteString(htmlHeader)
+	}
+	level, _ := event[LevelFieldName].(string)
+	fmt.Fprintf(buf, `<tr><td class="time">%s</td><td class="%s">%s</td><td>%s</td><td>%s`,
+		html.EscapeString(ConsoleWriterEx{}.formatTime(event[TimestampFieldName])),
+		html.EscapeString(level), html.EscapeString(strings.ToUpper(level)),
+		html.EscapeString(csvValue(event[CallerFieldName])),
+		html.EscapeString(csvValue(event[MessageFieldName])))
+	for _, k := range fieldNames(event) {
+		fmt.Fprintf(buf, ` <span class="field">%s</span>=%s`, html.EscapeString(k), html.EscapeString(quoteValue(event[k])))
+	}
+	buf.WriteString("</td></tr>\n")
+	if _, err := buf.WriteTo(w.out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *HTMLWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.header {
+		w.header = true
+		io.WriteString(w.out, htmlHeader)
+	}
+	_, err := io.WriteString(w.out, htmlFooter)
+	return err
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// jsonLinesWriter passes events through, each ending in exactly one
+// newline, so events written without one still form JSON Lines.
+type jsonLinesWriter struct {
+	out  io.Writer
+	mu   sync.Mutex
+	line []byte
+}
+
+func (w *jsonLinesWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.line = append(append(w.line[:0], trimNewline(p)...), '\n')
+	if _, err := w.out.Write(w.line); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// NewFormatWriter returns a sink rendering JSON events in the named
+// format: json (passthrough, one event per line), text (uncolored console), logfmt, csv, html
+// or the name of a registered Encoder such as msgpack.
+func NewFormatWriter(format string, out io.Writer) (io.WriteCloser, error) {
+	switch format {
+	case "json":
+		return nopCloser{&jsonLinesWriter{out: out}}, nil
+	case "text":
+		return nopCloser{ConsoleWriterEx{Out: out, NoColor: true}}, nil
+	case "logfmt":
+		return nopCloser{LogfmtWriter{Out: out}}, nil
+	case "csv":
+		return NewCSVWriter(out), nil
+	case "html":
+		return NewHTMLWriter(out), nil
+	}
+	if e, ok := LookupEncoder(format); ok {
+		return nopCloser{EncodeWriter{Next: out, Encoder: e}}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
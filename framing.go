@@ -0,0 +1,136 @@
+package consoleEx
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// Framing is how a FrameWriter delimits events for sinks that do not keep
+// lines whole, such as pipes and sockets splitting large writes.
+type Framing int
+
+const (
+	// FramingLength prefixes each event with its length in decimal and a
+	// space, as in RFC 6587 octet counting.
+	FramingLength Framing = iota
+	// FramingEscaped escapes backslashes and newlines inside each event,
+	// so the only raw newline is the one ending it.
+	FramingEscaped
+)
+
+var errBadFrame = errors.New("consoleEx: malformed frame")
+
+// FrameWriter writes each event to Next as one frame, without its trailing
+// newline, in a single Write. NewFrameScanner reads the frames back.
+type FrameWriter struct {
+	Next    io.Writer
+	Framing Framing
+}
+
+func (w FrameWriter) Write(p []byte) (int, error) {
+	if _, err := w.Next.Write(AppendFrame(nil, trimNewline(p), w.Framing)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// AppendFrame appends event framed with f to dst.
+func AppendFrame(dst, event []byte, f Framing) []byte {
+	if f == FramingLength {
+		dst = strconv.AppendInt(dst, int64(len(event)), 10)
+		dst = append(dst, ' ')
+		return append(dst, event...)
+	}
+	for _, c := range event {
+		switch c {
+		case '\\':
+			dst = append(dst, '\\', '\\')
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return append(dst, '\n')
+}
+
+// NewFrameScanner returns a scanner whose tokens are the events framed
+// with f in r, without trailing newlines. Its buffer grows to 64MB.
+func NewFrameScanner(r io.Reader, f Framing) *bufio.Scanner {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	s.Split(SplitFrames(f))
+	return s
+}
+
+// SplitFrames returns a bufio.SplitFunc for events framed with f.
+func SplitFrames(f Framing) bufio.SplitFunc {
+	if f == FramingLength {
+		return splitLengthFrames
+	}
+	return splitEscapedFrames
+}
+
+func splitLengthFrames(data []byte, atEOF bool) (int, []byte, error) {
+	sp := bytes.IndexByte(data, ' ')
+	if sp < 0 {
+		if atEOF && len(data) > 0 {
+			return 0, nil, errBadFrame
+		}
+		if len(data) > 20 {
+			return 0, nil, errBadFrame
+		}
+		return 0, nil, nil
+	}
+	n, err := strconv.Atoi(string(data[:sp]))
+	if err != nil || n < 0 {
+		return 0, nil, errBadFrame
+	}
+	end := sp + 1 + n
+	if end > len(data) {
+		if atEOF {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return 0, nil, nil
+	}
+	return end, data[sp+1 : end], nil
+}
+
+func splitEscapedFrames(data []byte, atEOF bool) (int, []byte, error) {
+	i := bytes.IndexByte(data, '\n')
+	if i < 0 {
+		if atEOF && len(data) > 0 {
+			return len(data), unescapeFrame(data), nil
+		}
+		return 0, nil, nil
+	}
+	return i + 1, unescapeFrame(data[:i]), nil
+}
+
+func unescapeFrame(p []byte) []byte {
+	if bytes.IndexByte(p, '\\') < 0 {
+		return p
+	}
+	out := make([]byte, 0, len(p))
+	for i := 0; i < len(p); i++ {
+		if p[i] == '\\' && i+1 < len(p) {
+			i++
+			if p[i] == 'n' {
+				out = append(out, '\n')
+				continue
+			}
+		}
+		out = append(out, p[i])
+	}
+	return out
+}
+
+// Frame returns a Decorator framing events with f, see FrameWriter.
+func Frame(f Framing) Decorator {
+	return func(next io.Writer) io.Writer {
+		return FrameWriter{Next: next, Framing: f}
+	}
+}
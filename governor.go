@@ -0,0 +1,52 @@
+package consoleEx
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+// LineGovernor caps how many events a ConsoleWriterEx renders per second,
+// keeping a terminal responsive during log storms. Events over the cap are
+// skipped, fatal and panic ones excepted, and counted in a summary line
+// shown with the first event of the next second. Only the console is
+// governed: files and network sinks next to it still get every event.
+type LineGovernor struct {
+	MaxLines int
+
+	mu         sync.Mutex
+	window     time.Time
+	lines      int
+	suppressed int
+	total      uint64
+}
+
+func NewLineGovernor(maxLines int) *LineGovernor {
+	return &LineGovernor{MaxLines: maxLines}
+}
+
+// admit reports whether an event at level may be rendered at now and how
+// many events the previous second suppressed, once per second.
+func (g *LineGovernor) admit(level string, now time.Time) (bool, int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	report := 0
+	if now.Sub(g.window) >= time.Second {
+		g.window, g.lines = now, 0
+		report, g.suppressed = g.suppressed, 0
+	}
+	if g.MaxLines > 0 && g.lines >= g.MaxLines && level != FatalLevel.String() && level != PanicLevel.String() {
+		g.suppressed++
+		atomic.AddUint64(&g.total, 1)
+		return false, 0
+	}
+	g.lines++
+	return true, report
+}
+
+// Suppressed returns the number of events skipped so far.
+func (g *LineGovernor) Suppressed() uint64 {
+	return atomic.LoadUint64(&g.total)
+}
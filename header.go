@@ -0,0 +1,80 @@
+package consoleEx
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+// HeaderFieldName marks the header event written at the top of log files.
+var HeaderFieldName = "log_header"
+
+// FileHeader describes the process writing a log file, so the file is
+// self-describing when looked at weeks later.
+type FileHeader struct {
+	Version  string            `json:"version,omitempty"`
+	Go       string            `json:"go"`
+	Hostname string            `json:"hostname,omitempty"`
+	PID      int               `json:"pid"`
+	Args     []string          `json:"args"`
+	Start    time.Time         `json:"start"`
+	Env      map[string]string `json:"env,omitempty"`
+}
+
+// NewFileHeader snapshots the running process. Only the environment
+// variables named in envAllowlist are recorded.
+func NewFileHeader(envAllowlist ...string) FileHeader {
+	h := FileHeader{
+		Go:    runtime.Version(),
+		PID:   os.Getpid(),
+		Args:  os.Args,
+		Start: time.Now(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		h.Version = bi.Main.Version
+	}
+	h.Hostname, _ = os.Hostname()
+	for _, k := range envAllowlist {
+		if v, ok := os.LookupEnv(k); ok {
+			if h.Env == nil {
+				h.Env = make(map[string]string)
+			}
+			h.Env[k] = v
+		}
+	}
+	return h
+}
+
+// WriteTo writes the header as a single JSON event.
+func (h FileHeader) WriteTo(w io.Writer) (int64, error) {
+	event := map[string]interface{}{
+		LevelFieldName:     InfoLevel.String(),
+		TimestampFieldName: time.Now().Format(TimeFieldFormat),
+		MessageFieldName:   "log file header",
+		HeaderFieldName:    h,
+	}
+	b, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(b, '\n'))
+	return int64(n), err
+}
+
+// writeHeaderIfEmpty writes h to f when f has no content yet.
+func writeHeaderIfEmpty(f *os.File, h *FileHeader) error {
+	if h == nil {
+		return nil
+	}
+	fi, err := f.Stat()
+	if err != nil || fi.Size() > 0 {
+		return err
+	}
+	_, err = h.WriteTo(f)
+	return err
+}
@@ -0,0 +1,375 @@
+package consoleEx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HTTPWriter batches events and POSTs them as newline delimited JSON, or
+// as a stream of Encoder encoded events, optionally compressed with Codec. Batches are sent when they reach
+// BatchSize events or BatchBytes bytes, every FlushInterval, on Flush and
+// on Close. Failed sends are retried MaxRetries times with backoff unless
+// the server answered with a 4xx status. Fatal and panic events are sent
+// at once, with everything batched before them, ignoring BandwidthLimit and
+// open buckets.
+//
+// With BucketSize set, each flush splits the batch by event timestamp into
+// buckets of that size, sorted by time, sent oldest first in separate
+// requests, as backends such as Loki or CloudWatch require. A bucket is held
+// back until OutOfOrderTolerance after it ends so late events can join it;
+// events for a bucket that was already sent are passed to OnError with
+// ErrOutOfOrder.
+//
+// Closing the writer or cancelling its context interrupts requests in
+// flight; what is left is then sent within DrainTimeout, and later writes
+// fail. The background flusher starts with the first Write; set the
+// fields before it.
+type HTTPWriter struct {
+	URL           string
+	Client        *http.Client
+	Header        http.Header
+	Codec         Codec
+	Encoder       Encoder
+	BatchSize     int
+	BatchBytes    int
+	FlushInterval time.Duration
+	MaxRetries    int
+	// BucketSize and OutOfOrderTolerance enable event time bucketing.
+	BucketSize          time.Duration
+	OutOfOrderTolerance time.Duration
+	// StampShipped adds ShippedAtFieldName with the time each batch is
+	// sent.
+	StampShipped bool
+	// Proxy, if set, routes requests through an http://, https:// or
+	// socks5:// proxy instead of the one from the environment. It applies
+	// when Client is nil or the default one.
+	Proxy *url.URL
+	// BandwidthLimit caps request bodies in bytes per second; 0 means no
+	// limit.
+	BandwidthLimit int64
+	// OnError, if set, is called with batches that could not be sent.
+	OnError func(err error, batch [][]byte)
+	// DeadLetter, if set, receives events rejected permanently, see
+	// IsPermanent.
+	DeadLetter *DeadLetterFile
+	// DrainTimeout bounds sending what is left on Close or when the
+	// context is done, 5s if zero.
+	DrainTimeout time.Duration
+
+	ctx context.Context
+	// sendCtx is ctx, also cancelled by Close, for requests in flight.
+	sendCtx context.Context
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	closed  bool
+	batch   [][]byte
+	size    int
+	sendMu  sync.Mutex
+	kick    chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+	start   sync.Once
+	closeMu sync.Once
+	limit   *bandwidthLimiter
+	// urgent lifts BandwidthLimit while a priority event is sent.
+	urgent  bool
+	dropped uint64
+	// shipped is the end of the newest bucket sent.
+	shipped time.Time
+	proxied struct {
+		sync.Once
+		c *http.Client
+	}
+}
+
+// defaultHTTPTimeout bounds each request of the clients HTTPWriter makes.
+const defaultHTTPTimeout = 30 * time.Second
+
+// defaultHTTPClient is http.DefaultClient with defaultHTTPTimeout.
+var defaultHTTPClient = &http.Client{Timeout: defaultHTTPTimeout}
+
+// NewHTTPWriter returns an HTTPWriter posting to url with a client timing
+// out requests after 30s, whose background flusher stops and drains when
+// ctx is done or Close is called.
+func NewHTTPWriter(ctx context.Context, url string) *HTTPWriter {
+	sendCtx, cancel := context.WithCancel(ctx)
+	w := &HTTPWriter{
+		URL:           url,
+		Client:        defaultHTTPClient,
+		Header:        make(http.Header),
+		BatchSize:     500,
+		BatchBytes:    1 << 20,
+		FlushInterval: time.Second,
+		MaxRetries:    3,
+		ctx:           ctx,
+		sendCtx:       sendCtx,
+		cancel:        cancel,
+		kick:          make(chan struct{}, 1),
+		done:          make(chan struct{}),
+	}
+	return w
+}
+
+func (w *HTTPWriter) loop() {
+	defer w.wg.Done()
+	interval := w.FlushInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-w.kick:
+		case <-w.ctx.Done():
+			w.drain()
+			return
+		case <-w.done:
+			return
+		}
+		w.Flush()
+	}
+}
+
+func (w *HTTPWriter) Write(p []byte) (int, error) {
+	w.start.Do(func() {
+		w.wg.Add(1)
+		go w.loop()
+	})
+	line := append([]byte(nil), trimNewline(p)...)
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return 0, errSinkClosed
+	}
+	w.batch = append(w.batch, line)
+	w.size += len(line) + 1
+	full := len(w.batch) >= w.BatchSize || w.size >= w.BatchBytes
+	w.mu.Unlock()
+	if IsPriority(p) {
+		w.sendMu.Lock()
+		defer w.sendMu.Unlock()
+		w.urgent = true
+		defer func() { w.urgent = false }()
+		return len(p), w.flushLocked(w.sendCtx, true)
+	}
+	if full {
+		select {
+		case w.kick <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Flush sends the pending batch and waits for the result. With bucketing,
+// buckets still open for late events are kept for a later flush.
+func (w *HTTPWriter) Flush() error {
+	w.sendMu.Lock()
+	defer w.sendMu.Unlock()
+	return w.flushLocked(w.sendCtx, false)
+}
+
+// drain refuses further writes and sends everything left within
+// DrainTimeout.
+func (w *HTTPWriter) drain() error {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+	timeout := w.DrainTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	w.sendMu.Lock()
+	defer w.sendMu.Unlock()
+	return w.flushLocked(ctx, true)
+}
+
+func (w *HTTPWriter) flushLocked(ctx context.Context, final bool) error {
+	w.mu.Lock()
+	batch := w.batch
+	w.batch, w.size = nil, 0
+	w.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+	if w.BucketSize <= 0 {
+		return w.sendReport(ctx, batch)
+	}
+	ready, held, late := w.bucketize(batch, time.Now(), final)
+	if len(held) > 0 {
+		w.mu.Lock()
+		for _, line := range held {
+			w.size += len(line) + 1
+		}
+		w.batch = append(held, w.batch...)
+		w.mu.Unlock()
+	}
+	if len(late) > 0 {
+		w.report(ErrOutOfOrder, late)
+	}
+	var err error
+	for _, b := range ready {
+		if serr := w.sendReport(ctx, b.lines); serr != nil && err == nil {
+			err = serr
+		}
+		w.shipped = b.end
+	}
+	return err
+}
+
+func (w *HTTPWriter) sendReport(ctx context.Context, batch [][]byte) error {
+	err := w.send(ctx, batch)
+	if err != nil {
+		w.report(err, batch)
+	}
+	return err
+}
+
+func (w *HTTPWriter) report(err error, batch [][]byte) {
+	atomic.AddUint64(&w.dropped, uint64(len(batch)))
+	if w.DeadLetter != nil && IsPermanent(err) {
+		w.DeadLetter.Add(w.URL, err, batch...)
+	}
+	if w.OnError != nil {
+		w.OnError(err, batch)
+	}
+}
+
+func (w *HTTPWriter) send(ctx context.Context, batch [][]byte) error {
+	body := &bytes.Buffer{}
+	var out io.Writer = body
+	var zw io.WriteCloser
+	if w.Codec != nil {
+		zw = w.Codec.NewWriter(body)
+		out = zw
+	}
+	var enc []byte
+	now := time.Now()
+	for _, line := range batch {
+		if w.StampShipped {
+			line = stampShipped(line, now)
+		}
+		if w.Encoder == nil {
+			out.Write(line)
+			out.Write([]byte{'\n'})
+			continue
+		}
+		event, err := DecodeEvent(line)
+		if err != nil {
+			continue
+		}
+		if enc, err = w.Encoder.Encode(enc[:0], event); err != nil {
+			continue
+		}
+		out.Write(enc)
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	}
+	var err error
+	backoff := 200 * time.Millisecond
+	for attempt := 0; attempt <= w.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+				backoff *= 2
+			case <-ctx.Done():
+				return err
+			}
+		}
+		var retry bool
+		retry, err = w.post(ctx, body.Bytes())
+		if err == nil || !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// post sends one request, reporting whether a failure is worth retrying.
+func (w *HTTPWriter) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	if w.BandwidthLimit > 0 && !w.urgent {
+		if w.limit == nil {
+			w.limit = newBandwidthLimiter(w.BandwidthLimit)
+		}
+		req.Body = io.NopCloser(limitedReader{ctx, bytes.NewReader(body), w.limit})
+		req.GetBody = nil
+	}
+	for k, v := range w.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if w.Encoder != nil {
+		req.Header.Set("Content-Type", w.Encoder.ContentType())
+	}
+	if w.Codec != nil {
+		req.Header.Set("Content-Encoding", w.Codec.Name())
+	}
+	resp, err := w.client().Do(req)
+	if err != nil {
+		return true, err
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return true, fmt.Errorf("consoleEx: %s: %s", w.URL, resp.Status)
+	}
+	return false, &RejectedError{URL: w.URL, Status: resp.Status, Body: strings.TrimSpace(string(msg))}
+}
+
+func (w *HTTPWriter) client() *http.Client {
+	isDefault := w.Client == nil || w.Client == http.DefaultClient || w.Client == defaultHTTPClient
+	if !isDefault {
+		return w.Client
+	}
+	if w.Proxy == nil {
+		return defaultHTTPClient
+	}
+	w.proxied.Do(func() {
+		w.proxied.c = &http.Client{Timeout: defaultHTTPTimeout, Transport: &http.Transport{Proxy: http.ProxyURL(w.Proxy)}}
+	})
+	return w.proxied.c
+}
+
+// Close interrupts requests in flight, stops the flusher and sends what
+// is left within DrainTimeout.
+func (w *HTTPWriter) Close() error {
+	w.closeMu.Do(func() {
+		w.start.Do(func() {})
+		w.cancel()
+		close(w.done)
+		w.wg.Wait()
+	})
+	return w.drain()
+}
+
+// Pressure reports the pending events against BatchSize, which they exceed
+// when sends fall behind, and the events that could not be sent.
+func (w *HTTPWriter) Pressure() Pressure {
+	w.mu.Lock()
+	queued := len(w.batch)
+	w.mu.Unlock()
+	return newPressure(queued, w.BatchSize, atomic.LoadUint64(&w.dropped))
+}
@@ -0,0 +1,56 @@
+package consoleEx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPWriterCloseInterruptsHungPost(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	w := NewHTTPWriter(context.Background(), srv.URL)
+	w.MaxRetries = 0
+	w.DrainTimeout = 100 * time.Millisecond
+	w.Write([]byte(`{"message":"one"}`))
+	go w.Flush()
+	time.Sleep(50 * time.Millisecond)
+	start := time.Now()
+	w.Close()
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("Close took %v with a hung request", d)
+	}
+	if _, err := w.Write([]byte(`{"message":"late"}`)); err != errSinkClosed {
+		t.Fatalf("Write after Close: got %v, want %v", err, errSinkClosed)
+	}
+}
+
+func TestHTTPWriterRefusesWritesAfterContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	w := NewHTTPWriter(ctx, srv.URL)
+	w.Write([]byte(`{"message":"one"}`))
+	cancel()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := w.Write([]byte(`{"message":"late"}`)); err == errSinkClosed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("writes still accepted after the context was cancelled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	w.Close()
+}
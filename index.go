@@ -0,0 +1,203 @@
+package consoleEx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+// IndexEntry records the byte offset of the first event of a level within
+// a time bucket, and the number of lines before it.
+type IndexEntry struct {
+	Time   time.Time `json:"time"`
+	Level  string    `json:"level"`
+	Offset int64     `json:"offset"`
+	Line   int64     `json:"line,omitempty"`
+}
+
+// IndexWriter appends events to a log file and maintains a sidecar index
+// file (the log file name plus ".idx") holding one IndexEntry per bucket
+// and level, so tools can seek by time without scanning the whole file.
+// LogFile, RotatingFile and DatedFile keep the same index with their Index
+// field set.
+type IndexWriter struct {
+	mu  sync.Mutex
+	f   *os.File
+	idx *fileIndex
+}
+
+// OpenIndexWriter opens filename for appending and its index next to it.
+// granularity is the bucket size, typically time.Minute or time.Hour.
+func OpenIndexWriter(filename string, granularity time.Duration) (*IndexWriter, error) {
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := openFileIndex(filename, granularity)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &IndexWriter{f: f, idx: idx}, nil
+}
+
+func (w *IndexWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n, err := w.f.Write(p)
+	if ierr := w.idx.add(p[:n]); err == nil {
+		err = ierr
+	}
+	return n, err
+}
+
+func (w *IndexWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := w.f.Close()
+	if ierr := w.idx.Close(); err == nil {
+		err = ierr
+	}
+	return err
+}
+
+// fileIndex maintains the index of a log file as events are appended to
+// it.
+type fileIndex struct {
+	f           *os.File
+	granularity time.Duration
+	offset      int64
+	line        int64
+	bucket      time.Time
+	seen        map[string]bool
+}
+
+// openFileIndex opens the index of the log file path for appending, or
+// starts it over if the log file is empty or missing. The lines already in
+// the log file are counted so entries carry line numbers.
+func openFileIndex(path string, granularity time.Duration) (*fileIndex, error) {
+	if granularity <= 0 {
+		granularity = time.Minute
+	}
+	x := &fileIndex{granularity: granularity, seen: make(map[string]bool)}
+	if f, err := os.Open(path); err == nil {
+		x.offset, x.line, err = countLines(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if x.offset == 0 {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(path+".idx", flags, 0666)
+	if err != nil {
+		return nil, err
+	}
+	x.f = f
+	return x, nil
+}
+
+func countLines(r io.Reader) (size, lines int64, err error) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		size += int64(n)
+		lines += int64(bytes.Count(buf[:n], []byte{'\n'}))
+		if err == io.EOF {
+			return size, lines, nil
+		}
+		if err != nil {
+			return size, lines, err
+		}
+	}
+}
+
+// add records p, which was just appended to the log file.
+func (x *fileIndex) add(p []byte) error {
+	offset, line := x.offset, x.line
+	x.offset += int64(len(p))
+	x.line += int64(bytes.Count(p, []byte{'\n'}))
+	event, err := DecodeEvent(p)
+	if err != nil {
+		return nil
+	}
+	t, ok := EventTime(event)
+	if !ok {
+		t = time.Now()
+	}
+	level, _ := event[LevelFieldName].(string)
+	bucket := t.Truncate(x.granularity)
+	if !bucket.Equal(x.bucket) {
+		x.bucket = bucket
+		x.seen = make(map[string]bool)
+	}
+	if x.seen[level] {
+		return nil
+	}
+	x.seen[level] = true
+	b, _ := json.Marshal(IndexEntry{Time: bucket, Level: level, Offset: offset, Line: line})
+	_, err = x.f.Write(append(b, '\n'))
+	return err
+}
+
+// Close closes the index file; a nil index is ignored.
+func (x *fileIndex) Close() error {
+	if x == nil {
+		return nil
+	}
+	return x.f.Close()
+}
+
+// ReadIndex loads the sidecar index of the log file filename.
+func ReadIndex(filename string) ([]IndexEntry, error) {
+	f, err := os.Open(filename + ".idx")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []IndexEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e IndexEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// SeekOffset returns the byte offset of the bucket containing since, which
+// is where a reader interested in events from since onwards should start.
+func SeekOffset(entries []IndexEntry, since time.Time) int64 {
+	off, _, _ := SeekPosition(entries, since)
+	return off
+}
+
+// SeekPosition is SeekOffset also returning the number of lines before the
+// offset; ok is false if the index predates line numbers.
+func SeekPosition(entries []IndexEntry, since time.Time) (offset, line int64, ok bool) {
+	var cur time.Time
+	ok = true
+	for _, e := range entries {
+		if e.Time.After(since) {
+			break
+		}
+		if !e.Time.Equal(cur) {
+			cur = e.Time
+			offset, line = e.Offset, e.Line
+			ok = offset == 0 || line > 0
+		}
+	}
+	return offset, line, ok
+}
@@ -0,0 +1,80 @@
+package consoleEx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPipelineIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := NewPipeline().JSONFile(path).Index(time.Minute).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{
+		`{"level":"info","time":"2024-06-01T10:00:00Z","message":"a"}`,
+		`{"level":"info","time":"2024-06-01T10:00:30Z","message":"b"}`,
+		`{"level":"info","time":"2024-06-01T10:01:00Z","message":"c"}`,
+	}
+	var offsets []int64
+	var off int64
+	for _, l := range lines {
+		offsets = append(offsets, off)
+		off += int64(len(l) + 1)
+		if _, err := w.Write([]byte(l + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+	entries, err := ReadIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("entries = %+v, want one per minute", entries)
+	}
+	since := time.Date(2024, 6, 1, 10, 1, 10, 0, time.UTC)
+	offset, line, ok := SeekPosition(entries, since)
+	if !ok || offset != offsets[2] || line != 2 {
+		t.Fatalf("SeekPosition = %d, %d, %v; want %d, 2, true", offset, line, ok, offsets[2])
+	}
+}
+
+func TestLogFileIndexContinues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	for i, l := range []string{
+		`{"level":"info","time":"2024-06-01T10:00:00Z"}`,
+		`{"level":"info","time":"2024-06-01T11:00:00Z"}`,
+	} {
+		f, err := OpenLogFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Index = time.Hour
+		f.Write([]byte(l + "\n"))
+		f.Close()
+		entries, _ := ReadIndex(path)
+		if len(entries) != i+1 || entries[i].Line != int64(i) {
+			t.Fatalf("after %d opens entries = %+v", i+1, entries)
+		}
+	}
+}
+
+func TestRotatingFileIndexFollowsBackup(t *testing.T) {
+	dir := t.TempDir()
+	w := NewRotatingFile(filepath.Join(dir, "app.log"), 60)
+	w.Index = time.Minute
+	line := []byte(`{"level":"info","time":"2024-06-01T10:00:00Z","message":"x"}` + "\n")
+	w.Write(line)
+	w.Write(line)
+	w.Close()
+	idx, _ := filepath.Glob(filepath.Join(dir, "app-*.log.idx"))
+	if len(idx) != 1 {
+		t.Fatalf("backup indexes = %v, want one", idx)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app.log.idx")); err != nil {
+		t.Fatal(err)
+	}
+}
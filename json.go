@@ -0,0 +1,71 @@
+package consoleEx
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// injectField sets "key":value in the JSON object in p, replacing the
+// value if key is a member already and adding it last otherwise, keeping
+// any trailing newline. p is returned unchanged if it is not an object.
+func injectField(p []byte, key string, value interface{}) []byte {
+	if !isJSONObject(p) {
+		return p
+	}
+	body := bytes.TrimRight(p, " \r\n\t")
+	k, _ := json.Marshal(key)
+	v, err := json.Marshal(value)
+	if err != nil {
+		return p
+	}
+	if start, end, ok := memberValue(body, k, key); ok {
+		out := make([]byte, 0, len(p)-(end-start)+len(v))
+		out = append(out, p[:start]...)
+		out = append(out, v...)
+		return append(out, p[end:]...)
+	}
+	out := make([]byte, 0, len(p)+len(k)+len(v)+2)
+	out = append(out, body[:len(body)-1]...)
+	if len(bytes.TrimSpace(body[1:len(body)-1])) > 0 {
+		out = append(out, ',')
+	}
+	out = append(out, k...)
+	out = append(out, ':')
+	out = append(out, v...)
+	out = append(out, '}')
+	return append(out, p[len(body):]...)
+}
+
+// isJSONObject reports whether p, whitespace aside, looks like a JSON
+// object.
+func isJSONObject(p []byte) bool {
+	body := bytes.TrimSpace(p)
+	return len(body) >= 2 && body[0] == '{' && body[len(body)-1] == '}'
+}
+
+// memberValue returns the byte range of the value of the top-level member
+// key in the object body, quoted is key as JSON.
+func memberValue(body, quoted []byte, key string) (start, end int, ok bool) {
+	if !bytes.Contains(body, quoted) {
+		return 0, 0, false
+	}
+	d := json.NewDecoder(bytes.NewReader(body))
+	if _, err := d.Token(); err != nil {
+		return 0, 0, false
+	}
+	for d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return 0, 0, false
+		}
+		var raw json.RawMessage
+		if err := d.Decode(&raw); err != nil {
+			return 0, 0, false
+		}
+		if name, _ := t.(string); name == key {
+			end := int(d.InputOffset())
+			return end - len(raw), end, true
+		}
+	}
+	return 0, 0, false
+}
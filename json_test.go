@@ -0,0 +1,21 @@
+package consoleEx
+
+import "testing"
+
+func TestInjectField(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`{}`, `{"seq":7}`},
+		{`{"a":1}` + "\n", `{"a":1,"seq":7}` + "\n"},
+		{`{"seq":1,"a":"x"}` + "\n", `{"seq":7,"a":"x"}` + "\n"},
+		{`{"a":{"seq":1},"seq":"old"}`, `{"a":{"seq":1},"seq":7}`},
+		{`{"a":"\"seq\":1"}`, `{"a":"\"seq\":1","seq":7}`},
+		{`not json`, `not json`},
+	}
+	for _, tt := range tests {
+		if got := string(injectField([]byte(tt.in), "seq", 7)); got != tt.want {
+			t.Errorf("injectField(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package consoleEx
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LazyTTL is how long a lazy field waits to be materialized before it is
+// forgotten, as happens when a filter drops its event.
+var LazyTTL = 5 * time.Minute
+
+// LazyValue is a field value computed only if its event gets as far as a
+// LazyWriter, for values too expensive to build for events that level
+// filters, sampling or VModule throw away:
+//
+//	log.Debug().Interface("dump", consoleEx.Lazy(func() interface{} {
+//		return dumpRequest(r)
+//	})).Msg("request")
+//
+// zerolog serializes it as a placeholder, which LazyWriter replaces with
+// the value. Sinks reached without passing a LazyWriter see the
+// placeholder, so put Materialize ahead of any fan-out.
+type LazyValue struct {
+	f func() interface{}
+}
+
+// Lazy returns a LazyValue computed by f.
+func Lazy(f func() interface{}) LazyValue {
+	return LazyValue{f}
+}
+
+type lazyEntry struct {
+	f       func() interface{}
+	created time.Time
+}
+
+var lazyFields = struct {
+	sync.Mutex
+	m     map[uint64]lazyEntry
+	next  uint64
+	purge time.Time
+}{m: make(map[uint64]lazyEntry)}
+
+var lazyPrefix = []byte(`{"$lazy":`)
+
+// MarshalJSON parks the function and writes a placeholder naming it. It
+// only runs for events zerolog did not discard.
+func (v LazyValue) MarshalJSON() ([]byte, error) {
+	id := atomic.AddUint64(&lazyFields.next, 1)
+	now := time.Now()
+	lazyFields.Lock()
+	lazyFields.m[id] = lazyEntry{v.f, now}
+	if now.Sub(lazyFields.purge) > LazyTTL {
+		for k, e := range lazyFields.m {
+			if now.Sub(e.created) > LazyTTL {
+				delete(lazyFields.m, k)
+			}
+		}
+		lazyFields.purge = now
+	}
+	lazyFields.Unlock()
+	return append(strconv.AppendUint(append([]byte(nil), lazyPrefix...), id, 10), '}'), nil
+}
+
+func takeLazy(id uint64) (func() interface{}, bool) {
+	lazyFields.Lock()
+	defer lazyFields.Unlock()
+	e, ok := lazyFields.m[id]
+	delete(lazyFields.m, id)
+	return e.f, ok
+}
+
+// LazyWriter replaces lazy field placeholders with their values before
+// passing events to Next. Placeholders that expired become null.
+type LazyWriter struct {
+	Next io.Writer
+}
+
+func (w LazyWriter) Write(p []byte) (int, error) {
+	if !bytes.Contains(p, lazyPrefix) {
+		return w.Next.Write(p)
+	}
+	if _, err := w.Next.Write(materialize(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func materialize(p []byte) []byte {
+	out := make([]byte, 0, len(p))
+	for {
+		i := bytes.Index(p, lazyPrefix)
+		if i < 0 {
+			return append(out, p...)
+		}
+		rest := p[i+len(lazyPrefix):]
+		id, end, ok := lazyID(rest)
+		if !ok {
+			out = append(out, p[:i+len(lazyPrefix)]...)
+			p = rest
+			continue
+		}
+		out = append(out, p[:i]...)
+		value := []byte("null")
+		if f, ok := takeLazy(id); ok {
+			if b, err := json.Marshal(f()); err == nil {
+				value = b
+			}
+		}
+		out = append(out, value...)
+		p = rest[end+1:]
+	}
+}
+
+// lazyID parses the id and closing brace following a placeholder prefix.
+func lazyID(p []byte) (uint64, int, bool) {
+	end := bytes.IndexByte(p, '}')
+	if end < 0 {
+		return 0, 0, false
+	}
+	id, err := strconv.ParseUint(string(p[:end]), 10, 64)
+	return id, end, err == nil
+}
+
+// Materialize returns a Decorator computing lazy fields, see LazyValue.
+func Materialize() Decorator {
+	return func(next io.Writer) io.Writer {
+		return LazyWriter{Next: next}
+	}
+}
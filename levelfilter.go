@@ -0,0 +1,84 @@
+package consoleEx
+
+import (
+	"io"
+
+	. "github.com/rs/zerolog"
+)
+
+// LevelFilterWriter passes events at MinLevel or above to Next and drops
+// the rest. Events without a level are passed on.
+//
+//	file := consoleEx.LevelFilterWriter{Next: f, MinLevel: zerolog.InfoLevel}
+type LevelFilterWriter struct {
+	Next     io.Writer
+	MinLevel Level
+}
+
+func (w LevelFilterWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(eventLevel(p), p)
+}
+
+// WriteLevel implements zerolog's LevelWriter, filtering on the level
+// zerolog passes instead of decoding p.
+func (w LevelFilterWriter) WriteLevel(level Level, p []byte) (int, error) {
+	if level != NoLevel && level < w.MinLevel {
+		return len(p), nil
+	}
+	if lw, ok := w.Next.(LevelWriter); ok {
+		return lw.WriteLevel(level, p)
+	}
+	return w.Next.Write(p)
+}
+
+// eventLevel returns the level of the event p, or NoLevel if it has none.
+func eventLevel(p []byte) Level {
+	event, err := DecodeEvent(p)
+	if err != nil {
+		return NoLevel
+	}
+	s, ok := event[LevelFieldName].(string)
+	if !ok {
+		return NoLevel
+	}
+	l, err := ParseLevel(s)
+	if err != nil {
+		return NoLevel
+	}
+	return l
+}
+
+// LevelFilter returns a Decorator dropping events below min, see
+// LevelFilterWriter.
+func LevelFilter(min Level) Decorator {
+	return func(next io.Writer) io.Writer {
+		return LevelFilterWriter{Next: next, MinLevel: min}
+	}
+}
+
+// LevelRouter writes each event to every route whose MinLevel it reaches,
+// decoding the level at most once, or not at all when zerolog passes it.
+// Like zerolog's MultiLevelWriter it writes to all routes and returns the
+// first error.
+//
+//	w := consoleEx.LevelRouter{
+//		{Next: console, MinLevel: zerolog.DebugLevel},
+//		{Next: file, MinLevel: zerolog.WarnLevel},
+//		{Next: alerts, MinLevel: zerolog.ErrorLevel},
+//	}
+type LevelRouter []LevelFilterWriter
+
+func (r LevelRouter) Write(p []byte) (int, error) {
+	return r.WriteLevel(eventLevel(p), p)
+}
+
+// WriteLevel implements zerolog's LevelWriter.
+func (r LevelRouter) WriteLevel(level Level, p []byte) (int, error) {
+	var err error
+	for _, route := range r {
+		if _, werr := route.WriteLevel(level, p); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return len(p), err
+}
@@ -0,0 +1,29 @@
+package consoleEx
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// linkCurrent atomically points link at target, so that `tail -F link`
+// follows rotations. It prefers a relative symlink and falls back to a
+// hard link where symlinks need privileges, as on Windows.
+func linkCurrent(target, link string) error {
+	tmp := link + ".tmp"
+	os.Remove(tmp)
+	rel := target
+	if r, err := filepath.Rel(filepath.Dir(link), target); err == nil {
+		rel = r
+	}
+	err := os.Symlink(rel, tmp)
+	if err != nil {
+		if err = os.Link(target, tmp); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package consoleEx
+
+import (
+	"strings"
+	"time"
+)
+
+// Translator localizes the level labels and timestamps rendered by
+// ConsoleWriterEx.
+type Translator interface {
+	TranslateLevel(level string) string
+	TranslateTime(t time.Time) string
+}
+
+// LocaleTranslator is a table driven Translator. Levels missing from the
+// table fall back to the upper-cased four letter label, an empty TimeLayout
+// falls back to RFC3339. Months and Weekdays replace the English names
+// produced by the layout, entries left empty are kept as is.
+type LocaleTranslator struct {
+	Levels     map[string]string
+	TimeLayout string
+	Months     [12]string
+	Weekdays   [7]string
+}
+
+// ZhCN renders levels and timestamps in simplified Chinese.
+var ZhCN = LocaleTranslator{
+	Levels: map[string]string{
+		"trace": "跟踪",
+		"debug": "调试",
+		"info":  "信息",
+		"warn":  "警告",
+		"error": "错误",
+		"fatal": "致命",
+		"panic": "恐慌",
+	},
+	TimeLayout: "2006年01月02日 15:04:05",
+}
+
+func (tr LocaleTranslator) TranslateLevel(level string) string {
+	if s, ok := tr.Levels[level]; ok {
+		return s
+	}
+	r := []rune(strings.ToUpper(level))
+	if len(r) > 4 {
+		r = r[:4]
+	}
+	return string(r)
+}
+
+func (tr LocaleTranslator) TranslateTime(t time.Time) string {
+	layout := tr.TimeLayout
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	s := t.Format(layout)
+	if m := tr.Months[t.Month()-1]; m != "" {
+		s = strings.Replace(s, t.Month().String(), m, -1)
+		s = strings.Replace(s, t.Month().String()[:3], m, -1)
+	}
+	if d := tr.Weekdays[t.Weekday()]; d != "" {
+		s = strings.Replace(s, t.Weekday().String(), d, -1)
+		s = strings.Replace(s, t.Weekday().String()[:3], d, -1)
+	}
+	return s
+}
@@ -0,0 +1,36 @@
+package consoleEx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTranslatedLevelFitsByRunes(t *testing.T) {
+	ru := LocaleTranslator{Levels: map[string]string{"error": "ОШИБКА", "info": "ИНФО"}}
+	if got := ru.TranslateLevel("предупреждение"); !utf8.ValidString(got) || got != "ПРЕД" {
+		t.Fatalf("TranslateLevel fallback = %q, want ПРЕД", got)
+	}
+	for _, c := range []struct {
+		w    ConsoleWriterEx
+		want string
+	}{
+		{ConsoleWriterEx{}, "|ОШИБ|"},
+		{ConsoleWriterEx{LevelWidth: 6}, "|ОШИБКА|"},
+		{ConsoleWriterEx{FullLevelNames: true}, "|ОШИБКА|"},
+	} {
+		var buf bytes.Buffer
+		c.w.Out, c.w.NoColor, c.w.Translator = &buf, true, ru
+		c.w.Write([]byte(`{"level":"error","message":"m"}`))
+		if !utf8.Valid(buf.Bytes()) || !strings.Contains(buf.String(), c.want) {
+			t.Errorf("rendered %q, want %s", buf.String(), c.want)
+		}
+	}
+	var buf bytes.Buffer
+	w := ConsoleWriterEx{Out: &buf, NoColor: true, Translator: ZhCN, LevelWidth: 3}
+	w.Write([]byte(`{"level":"info","message":"m"}`))
+	if !strings.Contains(buf.String(), "|信息 |") {
+		t.Errorf("rendered %q, want the label padded to LevelWidth", buf.String())
+	}
+}
@@ -0,0 +1,72 @@
+package consoleEx
+
+import (
+	"io"
+	"reflect"
+	"sync"
+)
+
+// LockMode selects how ConsoleWriterEx serializes writes to Out.
+type LockMode int
+
+const (
+	// LockNone relies on Out to keep each single-call event intact.
+	LockNone LockMode = iota
+	// LockOut serializes every ConsoleWriterEx sharing the same Out, that
+	// is an equal Out value, or for maps, slices and funcs the same
+	// underlying data. Outs of other types that cannot be compared share a
+	// lock per type.
+	LockOut
+)
+
+// outLocks holds a lock per Out while writes to it are in progress.
+var outLocks = struct {
+	sync.Mutex
+	m map[interface{}]*outLockEntry
+}{m: make(map[interface{}]*outLockEntry)}
+
+type outLockEntry struct {
+	sync.Mutex
+	refs int
+}
+
+type outPointerKey struct {
+	t reflect.Type
+	p uintptr
+}
+
+func outLockKey(out io.Writer) interface{} {
+	t := reflect.TypeOf(out)
+	if t == nil || t.Comparable() {
+		return out
+	}
+	switch v := reflect.ValueOf(out); v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Func:
+		return outPointerKey{t, v.Pointer()}
+	}
+	return t
+}
+
+// lockOut locks out against other ConsoleWriterEx values and returns the
+// function unlocking it. The entry is dropped once nobody holds or waits
+// for it.
+func lockOut(out io.Writer) (unlock func()) {
+	key := outLockKey(out)
+	outLocks.Lock()
+	e := outLocks.m[key]
+	if e == nil {
+		e = &outLockEntry{}
+		outLocks.m[key] = e
+	}
+	e.refs++
+	outLocks.Unlock()
+	e.Lock()
+	return func() {
+		e.Unlock()
+		outLocks.Lock()
+		if e.refs--; e.refs == 0 {
+			delete(outLocks.m, key)
+		}
+		outLocks.Unlock()
+	}
+}
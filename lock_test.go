@@ -0,0 +1,139 @@
+package consoleEx
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// overlapWriter fails the test if two writes are in progress at once and
+// keeps the lines it was given.
+type overlapWriter struct {
+	t      *testing.T
+	active int32
+	mu     sync.Mutex
+	lines  []string
+}
+
+func (w *overlapWriter) Write(p []byte) (int, error) {
+	if atomic.AddInt32(&w.active, 1) != 1 {
+		w.t.Error("concurrent writes to a locked Out")
+	}
+	runtime.Gosched()
+	w.mu.Lock()
+	w.lines = append(w.lines, string(p))
+	w.mu.Unlock()
+	atomic.AddInt32(&w.active, -1)
+	return len(p), nil
+}
+
+func produce(t *testing.T, writers, events int, w func(g int) ConsoleWriterEx) {
+	t.Helper()
+	var wg sync.WaitGroup
+	for g := 0; g < writers; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			cw := w(g)
+			for i := 0; i < events; i++ {
+				if _, err := fmt.Fprintf(cw, `{"level":"info","message":"g%d-%d","n":%d}`+"\n", g, i, i); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestLockOutSerializesWriters(t *testing.T) {
+	out := &overlapWriter{t: t}
+	produce(t, 8, 200, func(int) ConsoleWriterEx {
+		return ConsoleWriterEx{Out: out, NoColor: true, Lock: LockOut}
+	})
+	if len(out.lines) != 8*200 {
+		t.Fatalf("got %d lines, want %d", len(out.lines), 8*200)
+	}
+	for _, l := range out.lines {
+		if strings.Count(l, "\n") != 1 || !strings.Contains(l, "n=") {
+			t.Fatalf("torn line %q", l)
+		}
+	}
+}
+
+func TestSharedConsoleWriterConcurrent(t *testing.T) {
+	var mu sync.Mutex
+	var buf bytes.Buffer
+	out := lockedWriter{&mu, &buf}
+	cw := ConsoleWriterEx{Out: out, NoColor: true}
+	produce(t, 8, 200, func(int) ConsoleWriterEx { return cw })
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 8*200 {
+		t.Fatalf("got %d lines, want %d", len(lines), 8*200)
+	}
+	seen := make(map[string]bool)
+	for _, l := range lines {
+		i := strings.Index(l, "| g")
+		if i < 0 {
+			t.Fatalf("malformed line %q", l)
+		}
+		seen[strings.Fields(l[i+2:])[0]] = true
+	}
+	if len(seen) != 8*200 {
+		t.Fatalf("got %d distinct events, want %d", len(seen), 8*200)
+	}
+}
+
+type lockedWriter struct {
+	mu  *sync.Mutex
+	buf *bytes.Buffer
+}
+
+func (w lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestOutLocksReleased(t *testing.T) {
+	out := &overlapWriter{t: t}
+	produce(t, 4, 50, func(int) ConsoleWriterEx {
+		return ConsoleWriterEx{Out: out, NoColor: true, Lock: LockOut}
+	})
+	outLocks.Lock()
+	n := len(outLocks.m)
+	outLocks.Unlock()
+	if n != 0 {
+		t.Fatalf("%d out locks left after the writes finished", n)
+	}
+}
+
+type chanWriter chan []byte
+
+func (c chanWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+type funcWriter func([]byte) (int, error)
+
+func (f funcWriter) Write(p []byte) (int, error) { return f(p) }
+
+func TestOutLockKeys(t *testing.T) {
+	a, b := make(chanWriter), make(chanWriter)
+	if outLockKey(a) == outLockKey(b) {
+		t.Error("distinct channel Outs share a lock")
+	}
+	if outLockKey(a) != outLockKey(a) {
+		t.Error("the same channel Out has different locks")
+	}
+	f := funcWriter(func(p []byte) (int, error) { return len(p), nil })
+	g := funcWriter(func(p []byte) (int, error) { return 0, nil })
+	if outLockKey(f) != outLockKey(f) {
+		t.Error("the same func Out has different locks")
+	}
+	if outLockKey(f) == outLockKey(g) {
+		t.Error("distinct func Outs share a lock")
+	}
+}
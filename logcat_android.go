@@ -0,0 +1,56 @@
+//go:build android && cgo
+
+package consoleEx
+
+/*
+#cgo LDFLAGS: -llog
+#include <stdlib.h>
+#include <android/log.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	. "github.com/rs/zerolog"
+)
+
+// LogcatWriter forwards events to Android's logcat, mapping levels to log
+// priorities and tagging each event with its MobileTagFieldName, or Tag.
+type LogcatWriter struct {
+	Tag string
+}
+
+func NewLogcatWriter(tag string) LogcatWriter {
+	return LogcatWriter{Tag: tag}
+}
+
+func (w LogcatWriter) Write(p []byte) (int, error) {
+	event, err := DecodeEvent(p)
+	if err != nil {
+		event = map[string]interface{}{MessageFieldName: string(trimNewline(p))}
+	}
+	level, _ := event[LevelFieldName].(string)
+	tag := C.CString(mobileTag(event, w.Tag))
+	defer C.free(unsafe.Pointer(tag))
+	text := C.CString(mobileText(event))
+	defer C.free(unsafe.Pointer(text))
+	C.__android_log_write(C.int(logcatPriority(level)), tag, text)
+	return len(p), nil
+}
+
+func logcatPriority(level string) C.int {
+	switch level {
+	case TraceLevel.String():
+		return C.ANDROID_LOG_VERBOSE
+	case DebugLevel.String():
+		return C.ANDROID_LOG_DEBUG
+	case WarnLevel.String():
+		return C.ANDROID_LOG_WARN
+	case ErrorLevel.String():
+		return C.ANDROID_LOG_ERROR
+	case FatalLevel.String(), PanicLevel.String():
+		return C.ANDROID_LOG_FATAL
+	}
+	return C.ANDROID_LOG_INFO
+}
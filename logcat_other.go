@@ -0,0 +1,14 @@
+//go:build !(android && cgo)
+
+package consoleEx
+
+// LogcatWriter is only available in Android builds with cgo.
+type LogcatWriter struct {
+	Tag string
+}
+
+func NewLogcatWriter(tag string) LogcatWriter {
+	return LogcatWriter{Tag: tag}
+}
+
+func (w LogcatWriter) Write(p []byte) (int, error) { return 0, errSinkClosed }
@@ -0,0 +1,67 @@
+package consoleEx
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// DefaultMaxLineSize is the MaxLineSize of NewLowMemoryWriter.
+const DefaultMaxLineSize = 4096
+
+// LowMemoryWriter renders with Writer for embedded and edge devices: it
+// keeps a single reusable buffer instead of a pool and starts no
+// goroutines. Events longer than MaxLineSize are written raw, cut to
+// MaxLineSize, without being decoded, and rendered lines are cut to
+// MaxLineSize too. Memory per event is thus bounded by the decoded event
+// and a buffer of a few times MaxLineSize, which is given back whenever
+// an event grows it past 4*MaxLineSize. Writes are serialized.
+type LowMemoryWriter struct {
+	Writer      ConsoleWriterEx
+	MaxLineSize int
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// NewLowMemoryWriter returns a LowMemoryWriter rendering to out, configured
+// by opts, with DefaultMaxLineSize.
+func NewLowMemoryWriter(out io.Writer, opts ...Option) *LowMemoryWriter {
+	return &LowMemoryWriter{Writer: NewConsoleWriterEx(out, opts...), MaxLineSize: DefaultMaxLineSize}
+}
+
+func (l *LowMemoryWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	max := l.MaxLineSize
+	if max <= 0 {
+		max = DefaultMaxLineSize
+	}
+	l.buf.Reset()
+	out := l.Writer.Out
+	var err error
+	if len(p) > max {
+		l.buf.Write(p[:max])
+	} else if out, _, err = l.Writer.render(&l.buf, p); err != nil {
+		return 0, err
+	}
+	if l.buf.Len() > max {
+		l.buf.Truncate(max)
+		if l.Writer.colors() == colorANSI {
+			l.buf.WriteString("\x1b[0m")
+		}
+	}
+	if n := l.buf.Len(); n > 0 && l.buf.Bytes()[n-1] != '\n' {
+		l.buf.WriteByte('\n')
+	}
+	if l.buf.Len() > 0 && out != nil {
+		err = l.Writer.writeOut(out, &l.buf)
+	}
+	if l.buf.Cap() > 4*max {
+		l.buf = bytes.Buffer{}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,157 @@
+package consoleEx
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ManifestEntry describes one completed log file so shippers and auditors
+// can check they collected everything unaltered.
+type ManifestEntry struct {
+	// Path is relative to the manifest's directory when possible.
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// First and Last are the earliest and latest event times, nil if no
+	// event has one.
+	First  *time.Time `json:"first,omitempty"`
+	Last   *time.Time `json:"last,omitempty"`
+	Closed time.Time  `json:"closed"`
+}
+
+var manifestMu sync.Mutex
+
+// UpdateManifest records file in the JSON manifest at manifest, replacing
+// an earlier entry for the same file. The manifest is rewritten through a
+// temporary file and a rename, so readers never see it half written.
+func UpdateManifest(manifest, file string) (ManifestEntry, error) {
+	e, err := manifestEntry(file)
+	if err != nil {
+		return e, err
+	}
+	if rel, err := filepath.Rel(filepath.Dir(manifest), file); err == nil {
+		e.Path = filepath.ToSlash(rel)
+	}
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+	entries, err := ReadManifest(manifest)
+	if err != nil && !os.IsNotExist(err) {
+		return e, err
+	}
+	kept := entries[:0]
+	for _, old := range entries {
+		if old.Path != e.Path {
+			kept = append(kept, old)
+		}
+	}
+	entries = append(kept, e)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return e, err
+	}
+	tmp := manifest + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0644); err != nil {
+		return e, err
+	}
+	return e, os.Rename(tmp, manifest)
+}
+
+// ReadManifest returns the entries of a manifest written by
+// UpdateManifest.
+func ReadManifest(manifest string) ([]ManifestEntry, error) {
+	b, err := os.ReadFile(manifest)
+	if err != nil {
+		return nil, err
+	}
+	var entries []ManifestEntry
+	err = json.Unmarshal(b, &entries)
+	return entries, err
+}
+
+// VerifyManifest recomputes every entry and returns the ones whose file is
+// missing or no longer matches its size or checksum.
+func VerifyManifest(manifest string) ([]ManifestEntry, error) {
+	entries, err := ReadManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+	var bad []ManifestEntry
+	for _, e := range entries {
+		path := filepath.FromSlash(e.Path)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(manifest), path)
+		}
+		got, err := manifestEntry(path)
+		if err != nil || got.Size != e.Size || got.SHA256 != e.SHA256 {
+			bad = append(bad, e)
+		}
+	}
+	return bad, nil
+}
+
+// manifestEntry hashes file and reads its event time range in one pass,
+// through gzip for compressed files.
+func manifestEntry(file string) (ManifestEntry, error) {
+	e := ManifestEntry{Path: file, Closed: time.Now()}
+	f, err := os.Open(file)
+	if err != nil {
+		return e, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	raw := bufio.NewReader(io.TeeReader(f, io.MultiWriter(h, (*byteCounter)(&e.Size))))
+	var r *bufio.Reader = raw
+	if magic, _ := raw.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(raw)
+		if err != nil {
+			return e, err
+		}
+		r = bufio.NewReader(zr)
+	}
+	var first, last time.Time
+	for {
+		line, err := r.ReadBytes('\n')
+		if event, derr := DecodeEvent(line); derr == nil {
+			if t, ok := EventTime(event); ok {
+				if first.IsZero() || t.Before(first) {
+					first = t
+				}
+				if t.After(last) {
+					last = t
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return e, err
+		}
+	}
+	if _, err := io.Copy(io.Discard, raw); err != nil {
+		return e, err
+	}
+	e.SHA256 = hex.EncodeToString(h.Sum(nil))
+	if !first.IsZero() {
+		e.First, e.Last = &first, &last
+	}
+	return e, nil
+}
+
+// byteCounter counts the bytes written to it.
+type byteCounter int64
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
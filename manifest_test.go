@@ -0,0 +1,81 @@
+package consoleEx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManifestOmitsEmptyRange(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "manifest.json")
+	plain := filepath.Join(dir, "plain.log")
+	timed := filepath.Join(dir, "timed.log")
+	os.WriteFile(plain, []byte("no events here\n"), 0666)
+	os.WriteFile(timed, []byte(`{"time":"2024-06-01T10:00:00Z"}`+"\n"+`{"time":"2024-06-01T11:00:00Z"}`+"\n"), 0666)
+	e, err := UpdateManifest(manifest, plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.First != nil || e.Last != nil {
+		t.Fatalf("range of a file without times = %v, %v", e.First, e.Last)
+	}
+	e, err = UpdateManifest(manifest, timed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.First == nil || e.Last == nil || e.First.Hour() != 10 || e.Last.Hour() != 11 {
+		t.Fatalf("range = %v, %v", e.First, e.Last)
+	}
+	b, _ := os.ReadFile(manifest)
+	if strings.Contains(string(b), "0001-01-01") || strings.Count(string(b), `"first"`) != 1 {
+		t.Fatalf("manifest = %s", b)
+	}
+	if bad, err := VerifyManifest(manifest); err != nil || len(bad) != 0 {
+		t.Fatalf("VerifyManifest = %v, %v", bad, err)
+	}
+}
+
+func TestRotatingFileManifest(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "manifest.json")
+	w := NewRotatingFile(filepath.Join(dir, "app.log"), 60)
+	w.Manifest = manifest
+	w.Compress = true
+	line := []byte(`{"level":"info","time":"2024-06-01T10:00:00Z","message":"x"}` + "\n")
+	w.Write(line)
+	w.Write(line)
+	w.Close()
+	entries, err := ReadManifest(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !strings.HasSuffix(entries[0].Path, ".log.gz") || entries[0].First == nil {
+		t.Fatalf("entries = %+v, want the compressed backup with its time range", entries)
+	}
+	if bad, err := VerifyManifest(manifest); err != nil || len(bad) != 0 {
+		t.Fatalf("VerifyManifest = %v, %v", bad, err)
+	}
+}
+
+func TestDatedFileManifest(t *testing.T) {
+	chdirTemp(t)
+	w := NewDatedFile("app-2006-01-02T15-04-05.000000.log")
+	w.Manifest = "manifest.json"
+	w.Write([]byte(`{"message":"one"}` + "\n"))
+	first := w.Path()
+	for w.PathAt(time.Now()) == first {
+		time.Sleep(time.Millisecond)
+	}
+	w.Write([]byte(`{"message":"two"}` + "\n"))
+	w.Close()
+	entries, err := ReadManifest("manifest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Path != first || entries[1].Path != w.Path() {
+		t.Fatalf("entries = %+v, want %s and %s", entries, first, w.Path())
+	}
+}
@@ -0,0 +1,54 @@
+package consoleEx
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	. "github.com/rs/zerolog"
+)
+
+// MarkerFieldName identifies marker events.
+var MarkerFieldName = "marker"
+
+// Marker writes a marker event to the Default writer: consoles render it
+// as a full-width rule labeled with name, files keep the structured event.
+// Use it to separate test cases, deploy phases or retry attempts.
+func Marker(name string) error {
+	return MarkerTo(Default(), name)
+}
+
+// MarkerTo writes a marker event to w.
+func MarkerTo(w io.Writer, name string) error {
+	b, err := json.Marshal(map[string]interface{}{
+		LevelFieldName:     InfoLevel.String(),
+		TimestampFieldName: time.Now().Format(TimeFieldFormat),
+		MessageFieldName:   name,
+		MarkerFieldName:    name,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// markerRule renders the separator line for a marker labeled name.
+func markerRule(ts, name string, color colorMode) string {
+	width := 80
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 20 {
+		width = n
+	}
+	head := "──── " + ts + " "
+	label := " " + name + " "
+	fill := width - utf8.RuneCountInString(head) - utf8.RuneCountInString(label) - 4
+	if fill < 4 {
+		fill = 4
+	}
+	return colorize(head, cDarkGray, color) + colorize(label, cBold, color) +
+		colorize(strings.Repeat("─", fill+4), cDarkGray, color)
+}
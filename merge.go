@@ -0,0 +1,110 @@
+package consoleEx
+
+import (
+	"bufio"
+	"container/heap"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// OriginFieldName is the field Merge uses to tag each line with its source.
+var OriginFieldName = "origin"
+
+// MergeSource is one input of Merge, expected to be in chronological order
+// as written by a single logger.
+type MergeSource struct {
+	Name string
+	R    io.Reader
+}
+
+// Merge writes the events of all sources to out ordered by timestamp,
+// tagging each with its source name. Inputs are streamed, so memory use is
+// one line per source regardless of file size. Lines without a timestamp
+// keep the position of the line before them.
+func Merge(out io.Writer, sources ...MergeSource) error {
+	h := &mergeHeap{}
+	for i, src := range sources {
+		c := &mergeCursor{name: src.Name, order: i, sc: bufio.NewScanner(src.R)}
+		c.sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		if c.next() {
+			h.items = append(h.items, c)
+		} else if err := c.sc.Err(); err != nil {
+			return err
+		}
+	}
+	heap.Init(h)
+	bw := bufio.NewWriter(out)
+	for h.Len() > 0 {
+		c := h.items[0]
+		if _, err := bw.Write(c.line); err != nil {
+			return err
+		}
+		if c.next() {
+			heap.Fix(h, 0)
+			continue
+		}
+		if err := c.sc.Err(); err != nil {
+			return err
+		}
+		heap.Pop(h)
+	}
+	return bw.Flush()
+}
+
+// MergeFiles merges log files, tagging lines with the file base name.
+func MergeFiles(out io.Writer, paths ...string) error {
+	sources := make([]MergeSource, 0, len(paths))
+	for _, p := range paths {
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		sources = append(sources, MergeSource{Name: filepath.Base(p), R: f})
+	}
+	return Merge(out, sources...)
+}
+
+type mergeCursor struct {
+	name  string
+	order int
+	sc    *bufio.Scanner
+	line  []byte
+	t     time.Time
+}
+
+func (c *mergeCursor) next() bool {
+	if !c.sc.Scan() {
+		return false
+	}
+	line := append(c.sc.Bytes(), '\n')
+	if event, err := DecodeEvent(line); err == nil {
+		if t, ok := EventTime(event); ok {
+			c.t = t
+		}
+		line = injectField(line, OriginFieldName, c.name)
+	}
+	c.line = append(c.line[:0], line...)
+	return true
+}
+
+type mergeHeap struct{ items []*mergeCursor }
+
+func (h *mergeHeap) Len() int { return len(h.items) }
+func (h *mergeHeap) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if a.t.Equal(b.t) {
+		return a.order < b.order
+	}
+	return a.t.Before(b.t)
+}
+func (h *mergeHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *mergeHeap) Push(x interface{}) { h.items = append(h.items, x.(*mergeCursor)) }
+func (h *mergeHeap) Pop() interface{} {
+	n := len(h.items)
+	c := h.items[n-1]
+	h.items = h.items[:n-1]
+	return c
+}
@@ -0,0 +1,65 @@
+package consoleEx
+
+import (
+	"io"
+	"os"
+)
+
+// RecoverMmapFile repairs the tail of a file left behind by an MmapFile
+// that was not closed: it truncates the zero-filled preallocation and a
+// partially written last line, returning the resulting length.
+func RecoverMmapFile(path string) (int64, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := fi.Size()
+	end, err := lastByte(f, size, func(b byte) bool { return b != 0 })
+	if err != nil {
+		return 0, err
+	}
+	if end > 0 {
+		b := make([]byte, 1)
+		if _, err := f.ReadAt(b, end-1); err != nil {
+			return 0, err
+		}
+		if b[0] != '\n' {
+			if end, err = lastByte(f, end, func(b byte) bool { return b == '\n' }); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if end != size {
+		if err := f.Truncate(end); err != nil {
+			return 0, err
+		}
+	}
+	return end, nil
+}
+
+// lastByte scans f backwards from size and returns the offset just past
+// the last byte matching ok, or 0 if none does.
+func lastByte(f io.ReaderAt, size int64, ok func(byte) bool) (int64, error) {
+	buf := make([]byte, 64*1024)
+	for pos := size; pos > 0; {
+		n := int64(len(buf))
+		if n > pos {
+			n = pos
+		}
+		pos -= n
+		if _, err := f.ReadAt(buf[:n], pos); err != nil && err != io.EOF {
+			return 0, err
+		}
+		for i := n - 1; i >= 0; i-- {
+			if ok(buf[i]) {
+				return pos + i + 1, nil
+			}
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package consoleEx
+
+import "errors"
+
+// MmapFile is only available on unix systems.
+type MmapFile struct{}
+
+func OpenMmapFile(path string, chunk int64) (*MmapFile, error) {
+	return nil, errors.New("consoleEx: mmap file sink is not supported on this platform")
+}
+
+func (m *MmapFile) Write(p []byte) (int, error) { return 0, errSinkClosed }
+func (m *MmapFile) Sync() error                 { return errSinkClosed }
+func (m *MmapFile) Close() error                { return nil }
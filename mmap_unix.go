@@ -0,0 +1,118 @@
+//go:build unix
+
+package consoleEx
+
+import (
+	"os"
+	"sync"
+	"syscall"
+)
+
+// MmapFile is an append-only file sink writing through a shared memory
+// mapping, for write rates where a syscall per event is too expensive.
+// The file is grown in page-aligned chunks that the kernel zero fills, so
+// the committed length survives a crash implicitly: RecoverMmapFile, run by
+// OpenMmapFile, cuts trailing NUL bytes and a torn last line.
+type MmapFile struct {
+	mu    sync.Mutex
+	f     *os.File
+	chunk int64
+	data  []byte
+	base  int64
+	off   int64
+}
+
+// OpenMmapFile opens path for appending through windows of chunk bytes,
+// rounded up to the page size. chunk defaults to 64MiB.
+func OpenMmapFile(path string, chunk int64) (*MmapFile, error) {
+	if chunk <= 0 {
+		chunk = 64 << 20
+	}
+	page := int64(syscall.Getpagesize())
+	chunk = (chunk + page - 1) / page * page
+	size, err := RecoverMmapFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	m := &MmapFile{f: f, chunk: chunk, off: size}
+	if err := m.remap(0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// remap maps a window starting at the page containing off, large enough
+// for need more bytes, growing the file to cover it.
+func (m *MmapFile) remap(need int64) error {
+	if m.data != nil {
+		if err := syscall.Munmap(m.data); err != nil {
+			return err
+		}
+		m.data = nil
+	}
+	page := int64(syscall.Getpagesize())
+	m.base = m.off / page * page
+	size := m.chunk
+	for m.base+size < m.off+need {
+		size += m.chunk
+	}
+	if err := m.f.Truncate(m.base + size); err != nil {
+		return err
+	}
+	data, err := syscall.Mmap(int(m.f.Fd()), m.base, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return err
+	}
+	m.data = data
+	return nil
+}
+
+func (m *MmapFile) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.f == nil {
+		return 0, errSinkClosed
+	}
+	if m.off+int64(len(p)) > m.base+int64(len(m.data)) {
+		if err := m.remap(int64(len(p))); err != nil {
+			return 0, err
+		}
+	}
+	copy(m.data[m.off-m.base:], p)
+	m.off += int64(len(p))
+	return len(p), nil
+}
+
+// Sync flushes the mapped pages to disk.
+func (m *MmapFile) Sync() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.f == nil {
+		return errSinkClosed
+	}
+	return m.f.Sync()
+}
+
+// Close unmaps the file and truncates it to the written length.
+func (m *MmapFile) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.f == nil {
+		return nil
+	}
+	err := syscall.Munmap(m.data)
+	m.data = nil
+	if terr := m.f.Truncate(m.off); err == nil {
+		err = terr
+	}
+	if cerr := m.f.Close(); err == nil {
+		err = cerr
+	}
+	m.f = nil
+	return err
+}
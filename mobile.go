@@ -0,0 +1,38 @@
+package consoleEx
+
+import (
+	"strings"
+
+	. "github.com/rs/zerolog"
+)
+
+// MobileTagFieldName is the field naming the logcat tag or os_log category
+// of an event, falling back to the writer's own.
+var MobileTagFieldName = "component"
+
+// mobileText renders the message and fields of event for native mobile
+// logs, which record the time and level themselves.
+func mobileText(event map[string]interface{}) string {
+	var b strings.Builder
+	b.WriteString(valueString(event[MessageFieldName]))
+	for _, field := range fieldNames(event) {
+		if field == MobileTagFieldName {
+			continue
+		}
+		b.WriteString(" " + field + "=" + quoteValue(event[field]))
+	}
+	if caller, ok := event[CallerFieldName]; ok {
+		b.WriteString(" " + CallerFieldName + "=" + valueString(caller))
+	}
+	return b.String()
+}
+
+// mobileTag returns the tag of event, def if it has none.
+func mobileTag(event map[string]interface{}, def string) string {
+	if v, ok := event[MobileTagFieldName]; ok {
+		if s := valueString(v); s != "" {
+			return s
+		}
+	}
+	return def
+}
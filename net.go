@@ -0,0 +1,163 @@
+package consoleEx
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+var errSinkClosed = errors.New("consoleEx: sink closed")
+
+// NetWriter writes events to a TCP or UDP endpoint. The connection is
+// dialed on first use and re-dialed once when a write fails.
+type NetWriter struct {
+	Network     string
+	Addr        string
+	DialTimeout time.Duration
+	// TLS, if set, wraps TCP connections in TLS.
+	TLS *tls.Config
+	// Proxy, if set, tunnels TCP connections through a socks5:// or
+	// http:// proxy.
+	Proxy *url.URL
+	// BandwidthLimit caps outbound traffic in bytes per second; 0 means no
+	// limit. Fatal and panic events are never held back.
+	BandwidthLimit int64
+	// StampShipped adds ShippedAtFieldName with the time of each write.
+	StampShipped bool
+
+	ctx    context.Context
+	mu     sync.Mutex
+	conn   net.Conn
+	limit  *bandwidthLimiter
+	closed bool
+	done   chan struct{}
+}
+
+func NewNetWriter(network, addr string) *NetWriter {
+	return NewNetWriterContext(context.Background(), network, addr)
+}
+
+// NewNetWriterContext returns a NetWriter that is closed when ctx is done.
+// Dials are bounded by ctx and writes after cancellation fail.
+func NewNetWriterContext(ctx context.Context, network, addr string) *NetWriter {
+	w := &NetWriter{Network: network, Addr: addr, DialTimeout: 5 * time.Second, ctx: ctx, done: make(chan struct{})}
+	if ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				w.Close()
+			case <-w.done:
+			}
+		}()
+	}
+	return w
+}
+
+func (w *NetWriter) context() context.Context {
+	if w.ctx == nil {
+		return context.Background()
+	}
+	return w.ctx
+}
+
+func (w *NetWriter) dial() error {
+	d := &net.Dialer{Timeout: w.DialTimeout}
+	ctx := w.context()
+	var conn net.Conn
+	var err error
+	switch {
+	case w.Proxy != nil && w.Network == "udp":
+		return errors.New("consoleEx: proxy not supported for udp")
+	case w.Proxy != nil:
+		if w.DialTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, w.DialTimeout)
+			defer cancel()
+		}
+		conn, err = dialProxy(ctx, d, w.Proxy, w.Addr)
+		if err == nil && w.TLS != nil {
+			cfg := w.TLS.Clone()
+			if cfg.ServerName == "" {
+				cfg.ServerName, _, _ = net.SplitHostPort(w.Addr)
+			}
+			tc := tls.Client(conn, cfg)
+			if err = tc.HandshakeContext(ctx); err != nil {
+				conn.Close()
+			}
+			conn = tc
+		}
+	case w.TLS != nil:
+		conn, err = (&tls.Dialer{NetDialer: d, Config: w.TLS}).DialContext(ctx, w.Network, w.Addr)
+	default:
+		conn, err = d.DialContext(ctx, w.Network, w.Addr)
+	}
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	return nil
+}
+
+func (w *NetWriter) send(p []byte) (int, error) {
+	if w.BandwidthLimit <= 0 || IsPriority(p) {
+		return w.conn.Write(p)
+	}
+	if w.limit == nil {
+		w.limit = newBandwidthLimiter(w.BandwidthLimit)
+	}
+	return w.limit.write(w.context(), w.conn, p)
+}
+
+func (w *NetWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, errSinkClosed
+	}
+	if err := w.context().Err(); err != nil {
+		return 0, err
+	}
+	if w.conn == nil {
+		if err := w.dial(); err != nil {
+			return 0, err
+		}
+	}
+	q := p
+	if w.StampShipped {
+		q = stampShipped(p, time.Now())
+	}
+	if _, err := w.send(q); err == nil {
+		return len(p), nil
+	}
+	w.conn.Close()
+	w.conn = nil
+	if err := w.dial(); err != nil {
+		return 0, err
+	}
+	if _, err := w.send(q); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *NetWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.done != nil {
+		close(w.done)
+	}
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
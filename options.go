@@ -0,0 +1,214 @@
+package consoleEx
+
+import (
+	"io"
+	"os"
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+// Option configures a ConsoleWriterEx built by NewConsoleWriterEx.
+type Option func(*ConsoleWriterEx)
+
+// NewConsoleWriterEx returns a writer rendering to out, colored stdout if
+// out is nil, configured by opts. Defaults are filled in and settings
+// checked here, so new options do not change the behavior of a zero
+// ConsoleWriterEx literal.
+func NewConsoleWriterEx(out io.Writer, opts ...Option) ConsoleWriterEx {
+	w := ConsoleWriterEx{Out: out}
+	for _, opt := range opts {
+		opt(&w)
+	}
+	w.normalize()
+	return w
+}
+
+// normalize replaces unset or invalid settings with defaults.
+func (w *ConsoleWriterEx) normalize() {
+	if w.Out == nil {
+		w.Out = stdout()
+	}
+	if w.Layout < LayoutDefault || w.Layout > LayoutExpanded {
+		w.Layout = LayoutDefault
+	}
+	if w.Lock != LockOut {
+		w.Lock = LockNone
+	}
+}
+
+func WithNoColor(noColor bool) Option {
+	return func(w *ConsoleWriterEx) { w.NoColor = noColor }
+}
+
+func WithColorTags() Option {
+	return func(w *ConsoleWriterEx) { w.ColorTags = true }
+}
+
+func WithLock(mode LockMode) Option {
+	return func(w *ConsoleWriterEx) { w.Lock = mode }
+}
+
+func WithTranslator(t Translator) Option {
+	return func(w *ConsoleWriterEx) { w.Translator = t }
+}
+
+func WithSummary(s *Summary) Option {
+	return func(w *ConsoleWriterEx) { w.Summary = s }
+}
+
+// WithMinLevel drops events below l.
+func WithMinLevel(l Level) Option {
+	return func(w *ConsoleWriterEx) { w.MinLevel = &l }
+}
+
+func WithLayout(l Layout) Option {
+	return func(w *ConsoleWriterEx) { w.Layout = l }
+}
+
+// WithVerbosity applies the level and layout of v.
+func WithVerbosity(v Verbosity) Option {
+	return func(w *ConsoleWriterEx) { v.Apply(w) }
+}
+
+func WithFieldRules(r FieldRules) Option {
+	return func(w *ConsoleWriterEx) { w.FieldRules = r }
+}
+
+func WithDiff(d *FieldDiff) Option {
+	return func(w *ConsoleWriterEx) { w.Diff = d }
+}
+
+func WithTimeFormat(layout string) Option {
+	return func(w *ConsoleWriterEx) { w.TimeFormat = layout }
+}
+
+func WithTimeLocation(loc *time.Location) Option {
+	return func(w *ConsoleWriterEx) { w.TimeLocation = loc }
+}
+
+func WithFuncWidth(width int) Option {
+	return func(w *ConsoleWriterEx) { w.FuncWidth = width }
+}
+
+func WithTimeUnit(unit time.Duration) Option {
+	return func(w *ConsoleWriterEx) { w.TimeUnit = unit }
+}
+
+func WithPartsOrder(parts ...string) Option {
+	return func(w *ConsoleWriterEx) { w.PartsOrder = parts }
+}
+
+func WithFieldsOrder(fields ...string) Option {
+	return func(w *ConsoleWriterEx) { w.FieldsOrder = fields }
+}
+
+func WithStackFilter(f *StackFilter) Option {
+	return func(w *ConsoleWriterEx) { w.Stack = f }
+}
+
+func WithErrorChain() Option {
+	return func(w *ConsoleWriterEx) { w.ErrorChain = true }
+}
+
+func WithFieldsExclude(fields ...string) Option {
+	return func(w *ConsoleWriterEx) { w.FieldsExclude = fields }
+}
+
+func WithFieldsInclude(fields ...string) Option {
+	return func(w *ConsoleWriterEx) { w.FieldsInclude = fields }
+}
+
+func WithMultiErrors() Option {
+	return func(w *ConsoleWriterEx) { w.MultiErrors = true }
+}
+
+func WithFormatLevel(f Formatter) Option {
+	return func(w *ConsoleWriterEx) { w.FormatLevel = f }
+}
+
+func WithFormatCaller(f Formatter) Option {
+	return func(w *ConsoleWriterEx) { w.FormatCaller = f }
+}
+
+func WithFormatMessage(f Formatter) Option {
+	return func(w *ConsoleWriterEx) { w.FormatMessage = f }
+}
+
+func WithFormatFieldValue(f Formatter) Option {
+	return func(w *ConsoleWriterEx) { w.FormatFieldValue = f }
+}
+
+func WithFormatTimestamp(f Formatter) Option {
+	return func(w *ConsoleWriterEx) { w.FormatTimestamp = f }
+}
+
+func WithFormatFieldName(f Formatter) Option {
+	return func(w *ConsoleWriterEx) { w.FormatFieldName = f }
+}
+
+func WithTheme(t *Theme) Option {
+	return func(w *ConsoleWriterEx) { w.Theme = t }
+}
+
+// WithAutoColor sets NoColor and Profile from DetectColorProfile on the
+// writer given to NewConsoleWriterEx, stdout if it was nil.
+func WithAutoColor() Option {
+	return func(w *ConsoleWriterEx) {
+		var out io.Writer = os.Stdout
+		if w.Out != nil {
+			out = w.Out
+		}
+		w.Profile = DetectColorProfile(out)
+		w.NoColor = w.Profile == ProfileNoColor
+	}
+}
+
+func WithLevelColors(colors map[string]int) Option {
+	return func(w *ConsoleWriterEx) { w.LevelColors = colors }
+}
+
+func WithFullLevelNames() Option {
+	return func(w *ConsoleWriterEx) { w.FullLevelNames = true }
+}
+
+func WithLevelWidth(width int) Option {
+	return func(w *ConsoleWriterEx) { w.LevelWidth = width }
+}
+
+func WithTrimPaths() Option {
+	return func(w *ConsoleWriterEx) { w.TrimPaths = true }
+}
+
+func WithChecksum() Option {
+	return func(w *ConsoleWriterEx) { w.Checksum = true }
+}
+
+func WithGovernor(g *LineGovernor) Option {
+	return func(w *ConsoleWriterEx) { w.Governor = g }
+}
+
+// WithStderr sends events at warn and above to colored stderr, and the
+// rest to colored stdout unless NewConsoleWriterEx was given another
+// writer.
+func WithStderr() Option {
+	return func(w *ConsoleWriterEx) { w.ErrOut = stderr() }
+}
+
+func WithPlainOut(out io.Writer) Option {
+	return func(w *ConsoleWriterEx) { w.PlainOut = out }
+}
+
+// WithDeterministic renders output that is the same on every machine, for
+// golden test fixtures and CI logs: no color, UTC timestamps with
+// millisecond precision and trimmed source paths. Fields are always
+// sorted.
+func WithDeterministic() Option {
+	return func(w *ConsoleWriterEx) {
+		w.NoColor = true
+		w.ColorTags = false
+		w.TimeFormat = "2006-01-02T15:04:05.000Z07:00"
+		w.TimeLocation = time.UTC
+		w.TrimPaths = true
+	}
+}
@@ -0,0 +1,78 @@
+//go:build darwin && cgo
+
+package consoleEx
+
+/*
+#include <stdlib.h>
+#include <os/log.h>
+
+static void consoleex_os_log(os_log_t log, os_log_type_t type, const char *msg) {
+	os_log_with_type(log, type, "%{public}s", msg);
+}
+*/
+import "C"
+
+import (
+	"sync"
+	"unsafe"
+
+	. "github.com/rs/zerolog"
+)
+
+// OSLogWriter forwards events to Apple's unified logging under Subsystem,
+// usually the app's bundle identifier, mapping levels to log types and
+// filing each event under the category named by its MobileTagFieldName,
+// or Category. Messages are logged as public.
+type OSLogWriter struct {
+	Subsystem string
+	Category  string
+}
+
+func NewOSLogWriter(subsystem, category string) OSLogWriter {
+	return OSLogWriter{Subsystem: subsystem, Category: category}
+}
+
+var osLogs = struct {
+	sync.Mutex
+	m map[[2]string]C.os_log_t
+}{m: make(map[[2]string]C.os_log_t)}
+
+func (w OSLogWriter) Write(p []byte) (int, error) {
+	event, err := DecodeEvent(p)
+	if err != nil {
+		event = map[string]interface{}{MessageFieldName: string(trimNewline(p))}
+	}
+	level, _ := event[LevelFieldName].(string)
+	text := C.CString(mobileText(event))
+	defer C.free(unsafe.Pointer(text))
+	C.consoleex_os_log(w.log(mobileTag(event, w.Category)), osLogType(level), text)
+	return len(p), nil
+}
+
+// log returns the log object of category, created once per subsystem;
+// os_log objects are never released.
+func (w OSLogWriter) log(category string) C.os_log_t {
+	key := [2]string{w.Subsystem, category}
+	osLogs.Lock()
+	defer osLogs.Unlock()
+	l, ok := osLogs.m[key]
+	if !ok {
+		l = C.os_log_create(C.CString(w.Subsystem), C.CString(category))
+		osLogs.m[key] = l
+	}
+	return l
+}
+
+func osLogType(level string) C.os_log_type_t {
+	switch level {
+	case TraceLevel.String(), DebugLevel.String():
+		return C.OS_LOG_TYPE_DEBUG
+	case InfoLevel.String():
+		return C.OS_LOG_TYPE_INFO
+	case ErrorLevel.String():
+		return C.OS_LOG_TYPE_ERROR
+	case FatalLevel.String(), PanicLevel.String():
+		return C.OS_LOG_TYPE_FAULT
+	}
+	return C.OS_LOG_TYPE_DEFAULT
+}
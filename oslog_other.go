@@ -0,0 +1,15 @@
+//go:build !(darwin && cgo)
+
+package consoleEx
+
+// OSLogWriter is only available in darwin and iOS builds with cgo.
+type OSLogWriter struct {
+	Subsystem string
+	Category  string
+}
+
+func NewOSLogWriter(subsystem, category string) OSLogWriter {
+	return OSLogWriter{Subsystem: subsystem, Category: category}
+}
+
+func (w OSLogWriter) Write(p []byte) (int, error) { return 0, errSinkClosed }
@@ -0,0 +1,55 @@
+package consoleEx
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Pager feeds rendered output to an external pager process.
+type Pager struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+}
+
+// StartPager pipes output through $PAGER, or "less" with LESS=FRX so short
+// output is printed without paging, when out is a terminal. Otherwise, or
+// if the pager cannot be started, it returns out itself with a no-op Close.
+// Close the result to wait for the user to leave the pager.
+func StartPager(out *os.File) io.WriteCloser {
+	if !isTerminal(out.Fd()) {
+		return nopCloser{out}
+	}
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = "less"
+	}
+	args := strings.Fields(pager)
+	if len(args) == 0 || args[0] == "cat" {
+		return nopCloser{out}
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout, cmd.Stderr = out, os.Stderr
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nopCloser{out}
+	}
+	if err := cmd.Start(); err != nil {
+		return nopCloser{out}
+	}
+	return &Pager{cmd: cmd, in: in}
+}
+
+func (p *Pager) Write(b []byte) (int, error) {
+	return p.in.Write(b)
+}
+
+// Close ends the input and waits for the pager to exit.
+func (p *Pager) Close() error {
+	p.in.Close()
+	return p.cmd.Wait()
+}
@@ -0,0 +1,134 @@
+package consoleEx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+type panicConfig struct {
+	out     io.Writer
+	timeout time.Duration
+}
+
+var panicHandler atomic.Value
+
+// InstallPanicHandler makes Recover and Go log panics to w, or Default if
+// w is nil, as panic level events with the stack as pkg/errors style frames
+// in ErrorStackFieldName. Registered sinks are then drained with Shutdown,
+// waiting at most five seconds, and the panic continues.
+//
+// Go has no process wide panic hook, so only goroutines guarded by Recover
+// or Go are covered. If crashFile is set, on Go 1.23 and later the runtime's
+// own report of any other fatal panic is also appended to it.
+func InstallPanicHandler(w io.Writer, crashFile string) error {
+	if w == nil {
+		w = Default()
+	}
+	panicHandler.Store(panicConfig{out: w, timeout: 5 * time.Second})
+	if crashFile == "" {
+		return nil
+	}
+	f, err := os.OpenFile(crashFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if err := setCrashOutput(f); err != nil {
+		f.Close()
+		return err
+	}
+	return nil
+}
+
+// Recover logs and re-raises a panic of the calling goroutine. It must be
+// deferred directly:
+//
+//	defer consoleEx.Recover()
+func Recover() {
+	v := recover()
+	if v == nil {
+		return
+	}
+	cfg, ok := panicHandler.Load().(panicConfig)
+	if !ok {
+		cfg = panicConfig{out: Default(), timeout: 5 * time.Second}
+	}
+	writePanic(cfg.out, v, panicFrames())
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	Shutdown(ctx)
+	cancel()
+	panic(v)
+}
+
+// Go runs f in a new goroutine guarded by Recover.
+func Go(f func()) {
+	go func() {
+		defer Recover()
+		f()
+	}()
+}
+
+func writePanic(w io.Writer, v interface{}, frames []map[string]string) {
+	msg := fmt.Sprint(v)
+	if err, ok := v.(error); ok {
+		msg = err.Error()
+	}
+	b, err := json.Marshal(map[string]interface{}{
+		LevelFieldName:      PanicLevel.String(),
+		TimestampFieldName:  time.Now().Format(TimeFieldFormat),
+		MessageFieldName:    "panic: " + msg,
+		ErrorStackFieldName: frames,
+	})
+	if err != nil {
+		return
+	}
+	b = append(b, '\n')
+	if lw, ok := w.(LevelWriter); ok {
+		lw.WriteLevel(PanicLevel, b)
+		return
+	}
+	w.Write(b)
+}
+
+// panicFrames returns the stack of the panicking code, skipping the
+// runtime's panic machinery and Recover itself.
+func panicFrames() []map[string]string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var all []runtime.Frame
+	start := 0
+	for {
+		f, more := frames.Next()
+		if f.Function == "runtime.gopanic" {
+			start = len(all) + 1
+		}
+		all = append(all, f)
+		if !more {
+			break
+		}
+	}
+	out := make([]map[string]string, 0, len(all)-start)
+	for _, f := range all[start:] {
+		if strings.HasPrefix(f.Function, "runtime.") {
+			continue
+		}
+		name := f.Function[strings.LastIndexByte(f.Function, '/')+1:]
+		out = append(out, map[string]string{
+			"func":   name[strings.IndexByte(name, '.')+1:],
+			"source": filepath.Base(f.File),
+			"line":   strconv.Itoa(f.Line),
+		})
+	}
+	return out
+}
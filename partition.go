@@ -0,0 +1,118 @@
+package consoleEx
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Common directory layouts for PartitionedFile.
+const (
+	DailyLayout  = "2006/01/02"
+	HourlyLayout = "2006/01/02/15"
+)
+
+// PartitionedFile writes to Root/<time formatted DirLayout>/Name, e.g.
+// logs/2024/06/01/app.log, moving on to the next directory as soon as the
+// formatted path changes and creating directories as needed.
+type PartitionedFile struct {
+	Root      string
+	DirLayout string
+	Name      string
+	// UTC partitions by UTC instead of local time.
+	UTC bool
+	// Link, if set, is kept pointing at the active file, e.g. logs/app.log.
+	Link string
+	// Header, if set, is written at the top of every new file.
+	Header *FileHeader
+	// Manifest, if set, is the path of a manifest updated with every file
+	// when it is rotated or closed, see UpdateManifest.
+	Manifest string
+
+	mu   sync.Mutex
+	f    *os.File
+	path string
+}
+
+// NewPartitionedFile returns a daily partitioned file; set DirLayout to
+// HourlyLayout or any time layout for other schemes.
+func NewPartitionedFile(root, name string) *PartitionedFile {
+	return &PartitionedFile{Root: root, DirLayout: DailyLayout, Name: name}
+}
+
+// PathAt returns the file path used for events written at t.
+func (w *PartitionedFile) PathAt(t time.Time) string {
+	if w.UTC {
+		t = t.UTC()
+	}
+	layout := w.DirLayout
+	if layout == "" {
+		layout = DailyLayout
+	}
+	return filepath.Join(w.Root, filepath.FromSlash(t.Format(layout)), w.Name)
+}
+
+func (w *PartitionedFile) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if path := w.PathAt(time.Now()); path != w.path || w.f == nil {
+		if err := w.open(path); err != nil {
+			return 0, err
+		}
+	}
+	return w.f.Write(p)
+}
+
+func (w *PartitionedFile) open(path string) error {
+	if w.f != nil {
+		w.f.Close()
+		w.f = nil
+		w.record()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	if err := writeHeaderIfEmpty(f, w.Header); err != nil {
+		f.Close()
+		return err
+	}
+	w.f, w.path = f, path
+	if w.Link != "" {
+		linkCurrent(path, w.Link)
+	}
+	return nil
+}
+
+// Path returns the file currently written to, empty before the first write.
+func (w *PartitionedFile) Path() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.path
+}
+
+func (w *PartitionedFile) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	if err == nil && w.Manifest != "" {
+		_, err = UpdateManifest(w.Manifest, w.path)
+	}
+	return err
+}
+
+// record adds the file just rotated to the manifest. Failures are ignored
+// so that rotation never stops logging.
+func (w *PartitionedFile) record() {
+	if w.Manifest != "" {
+		UpdateManifest(w.Manifest, w.path)
+	}
+}
@@ -0,0 +1,84 @@
+package consoleEx
+
+import (
+	"bytes"
+
+	. "github.com/rs/zerolog"
+)
+
+// writeParts renders the header parts listed in PartsOrder separated by
+// spaces, leaving out time and caller in the compact layout and parts the
+// event lacks.
+func (w ConsoleWriterEx) writeParts(buf *bytes.Buffer, ts, level, where, msg string) {
+	n := 0
+	for _, part := range w.PartsOrder {
+		var s string
+		switch part {
+		case TimestampFieldName:
+			if w.Layout == LayoutCompact {
+				continue
+			}
+			s = ts
+		case LevelFieldName:
+			s = "|" + level + "|"
+		case CallerFieldName:
+			if w.Layout == LayoutCompact || where == "" {
+				continue
+			}
+			s = where
+		case MessageFieldName:
+			s = msg
+		default:
+			continue
+		}
+		if n > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(s)
+		n++
+	}
+}
+
+// pinFields moves the fields named in order to the front of fields.
+func pinFields(fields, order []string) []string {
+	present := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		present[f] = true
+	}
+	out := make([]string, 0, len(fields))
+	pinned := make(map[string]bool, len(order))
+	for _, f := range order {
+		if present[f] && !pinned[f] {
+			out = append(out, f)
+			pinned[f] = true
+		}
+	}
+	for _, f := range fields {
+		if !pinned[f] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// selectFields keeps the fields listed in include, or all if include is
+// nil, except those in exclude.
+func selectFields(fields, include, exclude []string) []string {
+	out := fields[:0]
+	for _, f := range fields {
+		if include != nil && !containsString(include, f) || containsString(exclude, f) {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
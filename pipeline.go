@@ -0,0 +1,142 @@
+package consoleEx
+
+import (
+	"io"
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+// PipelineBuilder assembles a writer from destinations that each choose
+// their format: colored console, plain text file or raw JSON file. Every
+// destination receives every event unless MinLevel raises its threshold.
+// Files are synced after fatal and panic events.
+//
+//	w, err := consoleEx.NewPipeline().
+//		Console(consoleEx.WithAutoColor()).
+//		PlainFile("app.txt").
+//		JSONFile("app.log").
+//		ErrorFile("app.error.log", zerolog.WarnLevel).
+//		Build()
+type PipelineBuilder struct {
+	router  LevelRouter
+	closers []io.Closer
+	last    *LogFile
+	err     error
+}
+
+func NewPipeline() *PipelineBuilder {
+	return &PipelineBuilder{}
+}
+
+// Console adds a console rendered to stdout, see NewConsoleWriterEx.
+func (b *PipelineBuilder) Console(opts ...Option) *PipelineBuilder {
+	return b.To(NewConsoleWriterEx(nil, opts...))
+}
+
+// PlainFile adds a file receiving uncolored console output, appended to.
+func (b *PipelineBuilder) PlainFile(path string, opts ...Option) *PipelineBuilder {
+	f := b.open(path)
+	if f == nil {
+		return b
+	}
+	opts = append([]Option{WithNoColor(true)}, opts...)
+	return b.To(NewConsoleWriterEx(f, opts...))
+}
+
+// ConsoleTee adds a console rendered to stdout whose output is also
+// appended to path without colors, rendering each event once, see
+// ConsoleWriterEx.PlainOut.
+func (b *PipelineBuilder) ConsoleTee(path string, opts ...Option) *PipelineBuilder {
+	f := b.open(path)
+	if f == nil {
+		return b
+	}
+	opts = append([]Option{WithPlainOut(f)}, opts...)
+	return b.Console(opts...)
+}
+
+// JSONFile adds a file receiving the events as zerolog wrote them,
+// appended to.
+func (b *PipelineBuilder) JSONFile(path string) *PipelineBuilder {
+	f := b.open(path)
+	if f == nil {
+		return b
+	}
+	b.To(f)
+	b.last = f
+	return b
+}
+
+// ErrorFile adds a JSON file receiving only the events at min or above,
+// such as app.error.log next to app.log with zerolog.WarnLevel.
+func (b *PipelineBuilder) ErrorFile(path string, min Level) *PipelineBuilder {
+	f := b.open(path)
+	if f == nil {
+		return b
+	}
+	b.To(f).MinLevel(min)
+	b.last = f
+	return b
+}
+
+// To adds w as a destination.
+func (b *PipelineBuilder) To(w io.Writer) *PipelineBuilder {
+	b.router = append(b.router, LevelFilterWriter{Next: w, MinLevel: TraceLevel})
+	b.last = nil
+	return b
+}
+
+// Index keeps a sidecar index with the given granularity for the JSON
+// file added last, see LogFile.Index; it does nothing after other
+// destinations.
+func (b *PipelineBuilder) Index(granularity time.Duration) *PipelineBuilder {
+	if b.last != nil {
+		b.last.Index = granularity
+	}
+	return b
+}
+
+// MinLevel drops events below l from the destination added last.
+func (b *PipelineBuilder) MinLevel(l Level) *PipelineBuilder {
+	if len(b.router) > 0 {
+		b.router[len(b.router)-1].MinLevel = l
+	}
+	return b
+}
+
+// Where drops the events match rejects from the destination added last,
+// routing events by a rule such as CompileExpr(`component == "auth"`).
+func (b *PipelineBuilder) Where(match Predicate) *PipelineBuilder {
+	if len(b.router) > 0 {
+		r := &b.router[len(b.router)-1]
+		r.Next = MatchWriter{Next: r.Next, Match: match}
+	}
+	return b
+}
+
+func (b *PipelineBuilder) open(path string) *LogFile {
+	if b.err != nil {
+		return nil
+	}
+	f, err := OpenLogFile(path)
+	if err != nil {
+		b.err = err
+		return nil
+	}
+	f.SyncOnFatal = true
+	b.closers = append(b.closers, f)
+	return f
+}
+
+// Build returns the writer, whose Close syncs and closes the files and
+// whose Reopen reopens them, or the first error met, with the files opened
+// so far closed.
+func (b *PipelineBuilder) Build() (io.WriteCloser, error) {
+	w := multiCloser{b.router, b.closers}
+	if b.err != nil {
+		w.Close()
+		return nil, b.err
+	}
+	return w, nil
+}
@@ -0,0 +1,47 @@
+//go:build consoleex_plugin
+
+package consoleEx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"plugin"
+)
+
+// LoadSinkPlugin opens a Go plugin built with -buildmode=plugin, running
+// its init functions, which can call RegisterSink to add their schemes.
+// Plugins must be built with the same toolchain and consoleEx version.
+func LoadSinkPlugin(path string) error {
+	_, err := plugin.Open(path)
+	return err
+}
+
+// openPluginSink opens the plugin named by the URI path and calls its
+// exported NewSink, a SinkFactory, with the URI.
+func openPluginSink(ctx context.Context, u *url.URL) (io.WriteCloser, error) {
+	name := sinkPath(u)
+	if name == "" {
+		return nil, fmt.Errorf("consoleEx: plugin sink needs a path")
+	}
+	p, err := plugin.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup("NewSink")
+	if err != nil {
+		return nil, err
+	}
+	switch f := sym.(type) {
+	case func(context.Context, *url.URL) (io.WriteCloser, error):
+		return f(ctx, u)
+	case *SinkFactory:
+		return (*f)(ctx, u)
+	}
+	return nil, fmt.Errorf("consoleEx: %s: NewSink is %T, not a SinkFactory", name, sym)
+}
+
+func init() {
+	RegisterSink("plugin", openPluginSink)
+}
@@ -0,0 +1,92 @@
+package consoleEx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	. "github.com/rs/zerolog"
+)
+
+// Predicate reports whether a decoded event matches.
+type Predicate func(event map[string]interface{}) bool
+
+var predicateOps = []string{"!=", ">=", "<=", "=", ">", "<", "~"}
+
+// ParsePredicate parses a structured predicate such as "level>=error",
+// "component=auth", "latency_ms>250" or "message~timeout". Levels compare
+// by severity, numbers numerically and everything else as strings; "~" is
+// a substring match.
+func ParsePredicate(s string) (Predicate, error) {
+	i := strings.IndexAny(s, "!=<>~")
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid predicate %q", s)
+	}
+	op := ""
+	for _, o := range predicateOps {
+		if strings.HasPrefix(s[i:], o) {
+			op = o
+			break
+		}
+	}
+	if op == "" {
+		return nil, fmt.Errorf("invalid predicate %q", s)
+	}
+	key, want := strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+len(op):])
+	if key == LevelFieldName && op != "~" {
+		wl, err := ParseLevel(want)
+		if err != nil {
+			return nil, err
+		}
+		return func(event map[string]interface{}) bool {
+			l, _ := event[LevelFieldName].(string)
+			el, err := ParseLevel(l)
+			if err != nil {
+				return op == "!="
+			}
+			return compare(op, int(el)-int(wl))
+		}, nil
+	}
+	wn, numErr := strconv.ParseFloat(want, 64)
+	return func(event map[string]interface{}) bool {
+		v, ok := event[key]
+		if !ok {
+			return op == "!="
+		}
+		if op == "~" {
+			return strings.Contains(fmt.Sprint(v), want)
+		}
+		if n, ok := v.(json.Number); ok && numErr == nil {
+			f, err := n.Float64()
+			if err == nil {
+				switch {
+				case f < wn:
+					return compare(op, -1)
+				case f > wn:
+					return compare(op, 1)
+				}
+				return compare(op, 0)
+			}
+		}
+		return compare(op, strings.Compare(fmt.Sprint(v), want))
+	}, nil
+}
+
+func compare(op string, c int) bool {
+	switch op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	}
+	return false
+}
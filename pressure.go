@@ -0,0 +1,90 @@
+package consoleEx
+
+import (
+	"sync"
+	"time"
+)
+
+// Pressure describes how far behind a queueing sink is. Queued and
+// Capacity are in the sink's own unit, events or bytes.
+type Pressure struct {
+	Queued   int
+	Capacity int
+	Dropped  uint64
+	// Load is the fill level of the fullest queue, from 0 to 1.
+	Load float64
+	// DropRate is drops per second, filled in by PipelinePressure.
+	DropRate float64
+}
+
+// Saturated reports whether the pipeline is dropping events or a queue is
+// at least 80% full, the point at which latency-critical code should log
+// less.
+func (p Pressure) Saturated() bool {
+	return p.DropRate > 0 || p.Load >= 0.8
+}
+
+// PressureReporter is implemented by sinks that queue events.
+type PressureReporter interface {
+	Pressure() Pressure
+}
+
+func newPressure(queued, capacity int, dropped uint64) Pressure {
+	p := Pressure{Queued: queued, Capacity: capacity, Dropped: dropped}
+	if capacity > 0 {
+		p.Load = float64(queued) / float64(capacity)
+		if p.Load > 1 {
+			p.Load = 1
+		}
+	}
+	return p
+}
+
+var pressureState struct {
+	sync.Mutex
+	at      time.Time
+	dropped uint64
+	rate    float64
+}
+
+// PipelinePressure combines the Pressure of the writers registered for
+// Shutdown: the highest Load, the total drops and the drop rate since the
+// previous call, measured over at least a second.
+//
+//	if consoleEx.PipelinePressure().Saturated() {
+//		log = log.Level(zerolog.WarnLevel)
+//	}
+func PipelinePressure() Pressure {
+	shutdownRegistry.Lock()
+	writers := append([]namedWriter(nil), shutdownRegistry.writers...)
+	shutdownRegistry.Unlock()
+	var total Pressure
+	for _, nw := range writers {
+		r, ok := nw.w.(PressureReporter)
+		if !ok {
+			continue
+		}
+		p := r.Pressure()
+		total.Queued += p.Queued
+		total.Capacity += p.Capacity
+		total.Dropped += p.Dropped
+		if p.Load > total.Load {
+			total.Load = p.Load
+		}
+	}
+	s := &pressureState
+	s.Lock()
+	defer s.Unlock()
+	now := time.Now()
+	switch {
+	case s.at.IsZero():
+		s.at, s.dropped = now, total.Dropped
+	case now.Sub(s.at) >= time.Second:
+		if total.Dropped >= s.dropped {
+			s.rate = float64(total.Dropped-s.dropped) / now.Sub(s.at).Seconds()
+		}
+		s.at, s.dropped = now, total.Dropped
+	}
+	total.DropRate = s.rate
+	return total
+}
@@ -0,0 +1,93 @@
+package consoleEx
+
+import (
+	"bytes"
+	"io"
+
+	. "github.com/rs/zerolog"
+)
+
+// IsPriority reports whether p is a fatal or panic event. Buffering and
+// rate limiting sinks write such events through at once, with whatever
+// they hold before them, and flush, so a process's last words are not
+// lost when it exits right after.
+func IsPriority(p []byte) bool {
+	if !bytes.Contains(p, []byte(FatalLevel.String())) && !bytes.Contains(p, []byte(PanicLevel.String())) {
+		return false
+	}
+	event, err := DecodeEvent(p)
+	if err != nil {
+		return false
+	}
+	l, _ := event[LevelFieldName].(string)
+	return l == FatalLevel.String() || l == PanicLevel.String()
+}
+
+// isPriorityLevel is IsPriority for a level zerolog passed.
+func isPriorityLevel(l Level) bool {
+	return l == FatalLevel || l == PanicLevel
+}
+
+// FlushAll flushes every writer registered for Shutdown without closing
+// it, returning the first error.
+func FlushAll() error {
+	shutdownRegistry.Lock()
+	writers := append([]namedWriter(nil), shutdownRegistry.writers...)
+	shutdownRegistry.Unlock()
+	var err error
+	for _, nw := range writers {
+		if f, ok := nw.w.(Flusher); ok {
+			if ferr := f.Flush(); ferr != nil && err == nil {
+				err = ferr
+			}
+		}
+	}
+	return err
+}
+
+// PriorityWriter passes events to Next and, after a fatal or panic event,
+// flushes Next and every registered writer before returning, so the event
+// is on disk and on the wire when zerolog calls os.Exit or panics.
+type PriorityWriter struct {
+	Next io.Writer
+}
+
+func (w PriorityWriter) Write(p []byte) (int, error) {
+	n, err := w.Next.Write(p)
+	if err != nil || !IsPriority(p) {
+		return n, err
+	}
+	if f, ok := w.Next.(Flusher); ok {
+		f.Flush()
+	}
+	FlushAll()
+	return n, nil
+}
+
+// WriteLevel decides on flushing from level instead of decoding p and
+// passes the level on if Next is a LevelWriter.
+func (w PriorityWriter) WriteLevel(level Level, p []byte) (int, error) {
+	var n int
+	var err error
+	if lw, ok := w.Next.(LevelWriter); ok {
+		n, err = lw.WriteLevel(level, p)
+	} else {
+		n, err = w.Next.Write(p)
+	}
+	if err != nil || level != FatalLevel && level != PanicLevel {
+		return n, err
+	}
+	if f, ok := w.Next.(Flusher); ok {
+		f.Flush()
+	}
+	FlushAll()
+	return n, nil
+}
+
+// Priority returns a Decorator flushing everything after fatal and panic
+// events, see PriorityWriter.
+func Priority() Decorator {
+	return func(next io.Writer) io.Writer {
+		return PriorityWriter{Next: next}
+	}
+}
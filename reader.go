@@ -0,0 +1,244 @@
+package consoleEx
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+// LogEvent is an event read back from a log file.
+type LogEvent struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	// Fields holds the whole decoded event, numbers as json.Number.
+	Fields map[string]interface{}
+	Raw    []byte
+	// Source and Line locate the event, Line counting from 1. Line is 0
+	// when an index without line numbers was used to seek into Source.
+	Source string
+	Line   int
+}
+
+// LogReader reads events back from files written by the file sinks, in the
+// style of bufio.Scanner. Gzip compressed files are detected and
+// decompressed, directories such as a PartitionedFile root are read in path
+// order, and sidecar indexes are used to seek when Since is set. File
+// headers are skipped, as are lines that are not JSON objects.
+//
+//	r, err := consoleEx.OpenLogReader("logs")
+//	r.Since = time.Now().Add(-time.Hour)
+//	for r.Scan() {
+//		e := r.Event()
+//	}
+//	err = r.Err()
+type LogReader struct {
+	// Since and Until select events in [Since, Until); events without a
+	// timestamp are left out when either is set.
+	Since, Until time.Time
+	// MinLevel, if set, drops events below it.
+	MinLevel *Level
+	// Match, if set, must accept the event.
+	Match Predicate
+
+	paths   []string
+	sc      *bufio.Scanner
+	file    io.Closer
+	name    string
+	line    int
+	noLine  bool
+	event   LogEvent
+	err     error
+	skipped int
+}
+
+// NewLogReader reads events from r.
+func NewLogReader(r io.Reader) *LogReader {
+	lr := &LogReader{name: "-"}
+	lr.sc = newLineScanner(r)
+	return lr
+}
+
+// OpenLogReader reads the given files and directories in order. Index
+// files, manifests and hidden files inside directories are ignored.
+func OpenLogReader(paths ...string) (*LogReader, error) {
+	r := &LogReader{}
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			r.paths = append(r.paths, p)
+			continue
+		}
+		var files []string
+		err = filepath.Walk(p, func(path string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() || !isLogFile(fi.Name()) {
+				return err
+			}
+			files = append(files, path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+		r.paths = append(r.paths, files...)
+	}
+	return r, nil
+}
+
+func isLogFile(name string) bool {
+	lower := strings.ToLower(name)
+	return !strings.HasPrefix(name, ".") && !strings.HasSuffix(lower, ".idx") &&
+		!strings.HasSuffix(lower, ".tmp") && !strings.HasSuffix(lower, "manifest.json")
+}
+
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	return sc
+}
+
+// open moves on to the next file, reporting false when none is left.
+func (r *LogReader) open() bool {
+	for len(r.paths) > 0 {
+		path := r.paths[0]
+		r.paths = r.paths[1:]
+		f, err := os.Open(path)
+		if err != nil {
+			r.err = err
+			return false
+		}
+		if fi, err := f.Stat(); err == nil && !r.Since.IsZero() && fi.ModTime().Before(r.Since) {
+			f.Close()
+			continue
+		}
+		br := bufio.NewReader(f)
+		var src io.Reader = br
+		if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+			zr, err := gzip.NewReader(br)
+			if err != nil {
+				f.Close()
+				r.err = err
+				return false
+			}
+			src = zr
+		}
+		line, noLine := 0, false
+		if src == br && !r.Since.IsZero() {
+			if idx, err := ReadIndex(path); err == nil {
+				if off, n, ok := SeekPosition(idx, r.Since); off > 0 {
+					f.Seek(off, io.SeekStart)
+					br.Reset(f)
+					line, noLine = int(n), !ok
+				}
+			}
+		}
+		r.file, r.sc, r.name, r.line, r.noLine = f, newLineScanner(src), path, line, noLine
+		return true
+	}
+	return false
+}
+
+// Scan advances to the next matching event.
+func (r *LogReader) Scan() bool {
+	for {
+		if r.err != nil {
+			return false
+		}
+		if r.sc == nil && !r.open() {
+			return false
+		}
+		if !r.sc.Scan() {
+			if err := r.sc.Err(); err != nil {
+				r.err = err
+				return false
+			}
+			r.sc = nil
+			r.closeFile()
+			if len(r.paths) == 0 {
+				return false
+			}
+			continue
+		}
+		r.line++
+		if e, ok := r.decode(r.sc.Bytes()); ok {
+			r.event = e
+			return true
+		}
+	}
+}
+
+func (r *LogReader) decode(line []byte) (LogEvent, bool) {
+	fields, err := DecodeEvent(line)
+	if err != nil {
+		r.skipped++
+		return LogEvent{}, false
+	}
+	if _, ok := fields[HeaderFieldName]; ok {
+		return LogEvent{}, false
+	}
+	e := LogEvent{Level: NoLevel, Fields: fields, Source: r.name, Line: r.line}
+	if r.noLine {
+		e.Line = 0
+	}
+	e.Time, _ = EventTime(fields)
+	if !r.Since.IsZero() || !r.Until.IsZero() {
+		if e.Time.IsZero() || (!r.Since.IsZero() && e.Time.Before(r.Since)) || (!r.Until.IsZero() && !e.Time.Before(r.Until)) {
+			return e, false
+		}
+	}
+	if l, ok := fields[LevelFieldName].(string); ok {
+		if lvl, err := ParseLevel(l); err == nil {
+			e.Level = lvl
+		}
+	}
+	if r.MinLevel != nil && e.Level != NoLevel && e.Level < *r.MinLevel {
+		return e, false
+	}
+	if r.Match != nil && !r.Match(fields) {
+		return e, false
+	}
+	e.Message, _ = fields[MessageFieldName].(string)
+	e.Raw = append([]byte(nil), line...)
+	return e, true
+}
+
+// Event returns the event found by the last successful Scan.
+func (r *LogReader) Event() LogEvent {
+	return r.event
+}
+
+// Err returns the first error other than reaching the end of the input.
+func (r *LogReader) Err() error {
+	return r.err
+}
+
+// Skipped reports how many lines were not valid JSON.
+func (r *LogReader) Skipped() int {
+	return r.skipped
+}
+
+func (r *LogReader) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// Close closes the file being read.
+func (r *LogReader) Close() error {
+	r.paths = nil
+	return r.closeFile()
+}
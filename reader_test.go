@@ -0,0 +1,54 @@
+package consoleEx
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLogReaderLineAfterSeek(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := OpenLogFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Index = time.Minute
+	for _, ts := range []string{"10:00:00", "10:01:00", "10:02:00"} {
+		f.Write([]byte(`{"level":"info","time":"2024-06-01T` + ts + `Z"}` + "\n"))
+	}
+	f.Close()
+	r, err := OpenLogReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	r.Since = time.Date(2024, 6, 1, 10, 2, 0, 0, time.UTC)
+	if !r.Scan() {
+		t.Fatal(r.Err())
+	}
+	if e := r.Event(); e.Line != 3 {
+		t.Fatalf("Line = %d, want 3", e.Line)
+	}
+}
+
+func TestLogReaderLineUnknownAfterOldIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	first := `{"level":"info","time":"2024-06-01T10:00:00Z"}` + "\n"
+	os.WriteFile(path, []byte(first+`{"level":"info","time":"2024-06-01T10:01:00Z"}`+"\n"), 0666)
+	os.WriteFile(path+".idx", []byte(`{"time":"2024-06-01T10:00:00Z","level":"info","offset":0}`+"\n"+
+		`{"time":"2024-06-01T10:01:00Z","level":"info","offset":`+strconv.Itoa(len(first))+`}`+"\n"), 0666)
+	r, err := OpenLogReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	r.Since = time.Date(2024, 6, 1, 10, 1, 0, 0, time.UTC)
+	if !r.Scan() {
+		t.Fatal(r.Err())
+	}
+	if e := r.Event(); e.Line != 0 {
+		t.Fatalf("Line = %d, want 0 after seeking with an old index", e.Line)
+	}
+}
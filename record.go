@@ -0,0 +1,101 @@
+package consoleEx
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Recording is one write captured by a Recorder: its offset from the start
+// of the recording and its exact bytes.
+type Recording struct {
+	At   time.Duration `json:"at"`
+	Data []byte        `json:"data"`
+}
+
+// Recorder captures every write, with its timing, to Out as one JSON
+// Recording per line, then passes it to Next if set. Replay feeds a
+// recording back into another writer, so themes, filters and sinks can be
+// tested against samples of real traffic.
+//
+//	f, _ := os.Create("session.rec")
+//	w := consoleEx.NewRecorder(f, file)
+type Recorder struct {
+	Out  io.Writer
+	Next io.Writer
+
+	mu    sync.Mutex
+	start time.Time
+}
+
+// NewRecorder returns a Recorder whose recording starts now.
+func NewRecorder(out, next io.Writer) *Recorder {
+	return &Recorder{Out: out, Next: next, start: time.Now()}
+}
+
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	if r.start.IsZero() {
+		r.start = time.Now()
+	}
+	b, err := json.Marshal(Recording{At: time.Since(r.start), Data: p})
+	if err == nil {
+		_, err = r.Out.Write(append(b, '\n'))
+	}
+	r.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	if r.Next == nil {
+		return len(p), nil
+	}
+	return r.Next.Write(p)
+}
+
+// Replay writes the recording read from rec to w. With speed 0 it writes
+// as fast as w accepts, for deterministic tests; otherwise it keeps the
+// recorded gaps between writes, divided by speed. It stops at the end of
+// the recording, on the first error or when ctx is done.
+func Replay(ctx context.Context, rec io.Reader, w io.Writer, speed float64) error {
+	s := bufio.NewScanner(rec)
+	s.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	start := time.Now()
+	for s.Scan() {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+		var r Recording
+		if err := json.Unmarshal(s.Bytes(), &r); err != nil {
+			return err
+		}
+		if speed > 0 {
+			wait := time.Duration(float64(r.At)/speed) - time.Since(start)
+			if wait > 0 {
+				t := time.NewTimer(wait)
+				select {
+				case <-t.C:
+				case <-ctx.Done():
+					t.Stop()
+					return ctx.Err()
+				}
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := w.Write(r.Data); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
+
+// Record returns a Decorator capturing the events reaching it to out, see
+// Recorder.
+func Record(out io.Writer) Decorator {
+	return func(next io.Writer) io.Writer {
+		return NewRecorder(out, next)
+	}
+}
@@ -0,0 +1,138 @@
+package consoleEx
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Redactor masks sensitive values in events.
+type Redactor struct {
+	// Fields are masked entirely wherever they appear, matched by name
+	// case-insensitively.
+	Fields []string
+	// Patterns are replaced inside every string value.
+	Patterns []*regexp.Regexp
+	// Mask replaces redacted content, "[REDACTED]" if empty.
+	Mask string
+	// When, if set, limits redaction to the events it matches, such as
+	// CompileExpr(`component == "payments"`).
+	When Predicate
+}
+
+// DefaultRedactor masks common credential fields, e-mail addresses, IPv4
+// addresses and card numbers.
+var DefaultRedactor = &Redactor{
+	Fields: []string{"password", "passwd", "secret", "token", "access_token",
+		"refresh_token", "authorization", "api_key", "apikey", "cookie", "set-cookie"},
+	Patterns: []*regexp.Regexp{
+		regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+		regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`),
+		regexp.MustCompile(`\b(?:\d[ \-]?){13,19}\b`),
+	},
+}
+
+func (r *Redactor) mask() string {
+	if r.Mask == "" {
+		return "[REDACTED]"
+	}
+	return r.Mask
+}
+
+func (r *Redactor) sensitive(field string) bool {
+	for _, f := range r.Fields {
+		if strings.EqualFold(f, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact masks the event in place and reports whether anything changed.
+func (r *Redactor) Redact(event map[string]interface{}) bool {
+	if r.When != nil && !r.When(event) {
+		return false
+	}
+	changed := false
+	for k, v := range event {
+		if r.sensitive(k) {
+			event[k] = r.mask()
+			changed = true
+			continue
+		}
+		if nv, ok := r.redactValue(v); ok {
+			event[k] = nv
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (r *Redactor) redactValue(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case string:
+		s := r.RedactString(v)
+		return s, s != v
+	case map[string]interface{}:
+		return v, r.Redact(v)
+	case []interface{}:
+		changed := false
+		for i := range v {
+			if nv, ok := r.redactValue(v[i]); ok {
+				v[i] = nv
+				changed = true
+			}
+		}
+		return v, changed
+	}
+	return v, false
+}
+
+// RedactString applies the patterns to s.
+func (r *Redactor) RedactString(s string) string {
+	for _, re := range r.Patterns {
+		s = re.ReplaceAllString(s, r.mask())
+	}
+	return s
+}
+
+// RedactLine redacts one JSON log line. Lines that are not JSON only have
+// the patterns applied. p is returned as is when nothing matched.
+func (r *Redactor) RedactLine(p []byte) []byte {
+	event, err := DecodeEvent(p)
+	if err != nil {
+		return []byte(r.RedactString(string(p)))
+	}
+	if !r.Redact(event) {
+		return p
+	}
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(event); err != nil {
+		return p
+	}
+	if !bytes.HasSuffix(p, []byte{'\n'}) {
+		buf.Truncate(buf.Len() - 1)
+	}
+	return buf.Bytes()
+}
+
+// RedactWriter redacts every event before passing it to Next.
+type RedactWriter struct {
+	Next     io.Writer
+	Redactor *Redactor
+}
+
+func (w RedactWriter) Write(p []byte) (int, error) {
+	r := w.Redactor
+	if r == nil {
+		r = DefaultRedactor
+	}
+	if _, err := w.Next.Write(r.RedactLine(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
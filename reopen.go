@@ -0,0 +1,163 @@
+package consoleEx
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+// Reopener is a file writer that can close and reopen its path, so writes
+// go to a new file after logrotate or another tool moved the old one away.
+type Reopener interface {
+	Reopen() error
+}
+
+// LogFile appends to Path and can be reopened.
+type LogFile struct {
+	Path string
+	// SyncOnFatal syncs the file after fatal and panic events, so they
+	// survive the exit that follows.
+	SyncOnFatal bool
+	// Index, if positive, keeps a sidecar index of the file, Path plus
+	// ".idx", with buckets of that size for seeking by time, see
+	// IndexWriter. It is started over when the file is new.
+	Index time.Duration
+
+	mu  sync.Mutex
+	f   *os.File
+	idx *fileIndex
+}
+
+// OpenLogFile opens path for appending, creating it if needed.
+func OpenLogFile(path string) (*LogFile, error) {
+	l := &LogFile{Path: path}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *LogFile) open() error {
+	f, err := os.OpenFile(l.Path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	l.f = f
+	return nil
+}
+
+func (l *LogFile) Write(p []byte) (int, error) {
+	return l.write(p, l.SyncOnFatal && IsPriority(p))
+}
+
+// WriteLevel implements zerolog's LevelWriter, telling fatal and panic
+// events by level instead of decoding p.
+func (l *LogFile) WriteLevel(level Level, p []byte) (int, error) {
+	return l.write(p, l.SyncOnFatal && isPriorityLevel(level))
+}
+
+func (l *LogFile) write(p []byte, sync bool) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		if err := l.open(); err != nil {
+			return 0, err
+		}
+	}
+	if l.Index > 0 && l.idx == nil {
+		idx, err := openFileIndex(l.Path, l.Index)
+		if err != nil {
+			return 0, err
+		}
+		l.idx = idx
+	}
+	n, err := l.f.Write(p)
+	if l.idx != nil {
+		if ierr := l.idx.add(p[:n]); err == nil {
+			err = ierr
+		}
+	}
+	if err == nil && sync {
+		err = l.f.Sync()
+	}
+	return n, err
+}
+
+// Reopen syncs and closes the file and opens Path again.
+func (l *LogFile) Reopen() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f != nil {
+		l.f.Sync()
+		l.f.Close()
+		l.f = nil
+	}
+	l.idx.Close()
+	l.idx = nil
+	return l.open()
+}
+
+// Flush syncs the file.
+func (l *LogFile) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	return l.f.Sync()
+}
+
+// Close syncs and closes the file. Writes after it reopen Path.
+func (l *LogFile) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.idx.Close()
+	l.idx = nil
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Sync()
+	if cerr := l.f.Close(); cerr != nil {
+		err = cerr
+	}
+	l.f = nil
+	return err
+}
+
+// ReopenOnSignal reopens files whenever the process receives sig, usually
+// syscall.SIGHUP sent by logrotate's postrotate script. Failures are
+// reported through SetDiagnostics. Calling stop ends it.
+//
+//	w, _ := consoleEx.NewWriter("app.log", true)
+//	stop := consoleEx.ReopenOnSignal(syscall.SIGHUP, w.(consoleEx.Reopener))
+func ReopenOnSignal(sig os.Signal, files ...Reopener) (stop func()) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sig)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-c:
+				for _, f := range files {
+					if err := f.Reopen(); err != nil {
+						if e := diag(ErrorLevel); e != nil {
+							e.Err(err).Msg("reopen failed")
+						}
+					}
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(c)
+			close(done)
+		})
+	}
+}
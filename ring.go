@@ -0,0 +1,119 @@
+package consoleEx
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+// RingEntry is one event kept by a RingBuffer.
+type RingEntry struct {
+	Seq   uint64
+	Time  time.Time
+	Level Level
+	Data  []byte
+}
+
+// RingBuffer is a sink keeping the last events in memory.
+type RingBuffer struct {
+	mu      sync.Mutex
+	entries []RingEntry
+	next    int
+	full    bool
+	seq     uint64
+}
+
+// NewRingBuffer returns a RingBuffer holding up to size events.
+func NewRingBuffer(size int) *RingBuffer {
+	if size <= 0 {
+		size = 1000
+	}
+	return &RingBuffer{entries: make([]RingEntry, size)}
+}
+
+func (r *RingBuffer) Write(p []byte) (int, error) {
+	e := RingEntry{Time: time.Now(), Level: NoLevel, Data: append([]byte(nil), p...)}
+	if event, err := DecodeEvent(p); err == nil {
+		if t, ok := EventTime(event); ok {
+			e.Time = t
+		}
+		if s, ok := event[LevelFieldName].(string); ok {
+			if l, err := ParseLevel(s); err == nil {
+				e.Level = l
+			}
+		}
+	}
+	r.add(e)
+	return len(p), nil
+}
+
+func (r *RingBuffer) add(e RingEntry) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	e.Seq = r.seq
+	r.entries[r.next] = e
+	r.next++
+	if r.next == len(r.entries) {
+		r.next = 0
+		r.full = true
+	}
+	return e.Seq
+}
+
+// Snapshot returns the buffered events, oldest first.
+func (r *RingBuffer) Snapshot() []RingEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]RingEntry(nil), r.entries[:r.next]...)
+	}
+	out := make([]RingEntry, 0, len(r.entries))
+	out = append(out, r.entries[r.next:]...)
+	return append(out, r.entries[:r.next]...)
+}
+
+// ServeHTTP responds with the buffered events as a JSON array, optionally
+// limited with ?level=warn and ?n=100.
+func (r *RingBuffer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	min := TraceLevel
+	if s := req.URL.Query().Get("level"); s != "" {
+		l, err := ParseLevel(s)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		min = l
+	}
+	entries := r.Snapshot()
+	if n, err := strconv.Atoi(req.URL.Query().Get("n")); err == nil && n >= 0 && n < len(entries) {
+		entries = entries[len(entries)-n:]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte{'['})
+	first := true
+	for _, e := range entries {
+		if e.Level < min && e.Level != NoLevel {
+			continue
+		}
+		if _, err := DecodeEvent(e.Data); err != nil {
+			continue
+		}
+		if !first {
+			w.Write([]byte{','})
+		}
+		first = false
+		w.Write(trimNewline(e.Data))
+	}
+	w.Write([]byte("]\n"))
+}
+
+func trimNewline(p []byte) []byte {
+	for len(p) > 0 && (p[len(p)-1] == '\n' || p[len(p)-1] == '\r') {
+		p = p[:len(p)-1]
+	}
+	return p
+}
@@ -0,0 +1,322 @@
+package consoleEx
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+// backupTimeLayout stamps the names of rotated files, sorting by time.
+const backupTimeLayout = "20060102T150405.000"
+
+// RotatingFile appends to Path and, when a write would take it past
+// MaxSize bytes, renames it to a backup named after the time, such as
+// app-20240601T101500.000.log, and starts a new file. Backups beyond the
+// newest MaxBackups, or older than MaxAge, are deleted; zero keeps them.
+type RotatingFile struct {
+	Path       string
+	MaxSize    int64
+	MaxBackups int
+	MaxAge     time.Duration
+	// Header, if set, is written at the top of every new file.
+	Header *FileHeader
+	// Compress gzips each backup to <backup>.gz in the background, removing
+	// the backup only once the compressed copy is in place.
+	Compress bool
+	// OnError, if set, is called when a backup could not be compressed.
+	OnError func(err error, path string)
+	// SyncOnFatal syncs the file after fatal and panic events, so they
+	// survive the exit that follows.
+	SyncOnFatal bool
+	// Index, if positive, keeps a sidecar index of the file as LogFile
+	// does. Backups keep theirs, <backup>.idx, unless they are compressed.
+	Index time.Duration
+	// Mode is the permission of new files, 0666 before the umask if zero.
+	Mode os.FileMode
+	// Manifest, if set, is the path of a manifest updated with every
+	// backup, once compressed if Compress is set, see UpdateManifest.
+	Manifest string
+
+	mu   sync.Mutex
+	f    *os.File
+	idx  *fileIndex
+	size int64
+	wg   sync.WaitGroup
+}
+
+// NewRotatingFile returns a RotatingFile rolling path at maxSize bytes and
+// keeping every backup.
+func NewRotatingFile(path string, maxSize int64) *RotatingFile {
+	return &RotatingFile{Path: path, MaxSize: maxSize}
+}
+
+func (w *RotatingFile) Write(p []byte) (int, error) {
+	return w.write(p, w.SyncOnFatal && IsPriority(p))
+}
+
+// WriteLevel implements zerolog's LevelWriter, see LogFile.WriteLevel.
+func (w *RotatingFile) WriteLevel(level Level, p []byte) (int, error) {
+	return w.write(p, w.SyncOnFatal && isPriorityLevel(level))
+}
+
+func (w *RotatingFile) write(p []byte, sync bool) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if w.MaxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.MaxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	if w.Index > 0 && w.idx == nil {
+		idx, err := openFileIndex(w.Path, w.Index)
+		if err != nil {
+			return 0, err
+		}
+		w.idx = idx
+	}
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+	if w.idx != nil {
+		if ierr := w.idx.add(p[:n]); err == nil {
+			err = ierr
+		}
+	}
+	if err == nil && sync {
+		err = w.f.Sync()
+	}
+	return n, err
+}
+
+func (w *RotatingFile) open() error {
+	if dir := filepath.Dir(w.Path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	mode := w.Mode
+	if mode == 0 {
+		mode = 0666
+	}
+	f, err := os.OpenFile(w.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, mode)
+	if err != nil {
+		return err
+	}
+	if err := writeHeaderIfEmpty(f, w.Header); err != nil {
+		f.Close()
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.f, w.size = f, fi.Size()
+	return nil
+}
+
+// Rotate moves the current file to a backup now, whatever its size.
+func (w *RotatingFile) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.rotate()
+}
+
+func (w *RotatingFile) rotate() error {
+	if w.f != nil {
+		w.f.Close()
+		w.f = nil
+	}
+	indexed := w.idx != nil
+	w.idx.Close()
+	w.idx = nil
+	t := time.Now()
+	for {
+		if _, err := os.Lstat(w.backupName(t)); os.IsNotExist(err) {
+			break
+		}
+		t = t.Add(time.Millisecond)
+	}
+	backup := w.backupName(t)
+	err := os.Rename(w.Path, backup)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if indexed && w.Compress {
+		os.Remove(w.Path + ".idx")
+	} else if indexed {
+		os.Rename(w.Path+".idx", backup+".idx")
+	}
+	if err == nil && w.Compress {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			if err := compressFile(backup); err != nil {
+				if w.OnError != nil {
+					w.OnError(err, backup)
+				}
+				w.record(backup)
+			} else {
+				w.record(backup + ".gz")
+			}
+			w.prune()
+		}()
+	} else {
+		if err == nil {
+			w.record(backup)
+		}
+		w.prune()
+	}
+	return w.open()
+}
+
+func (w *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(w.Path)
+	return strings.TrimSuffix(w.Path, ext) + "-" + t.Format(backupTimeLayout) + ext
+}
+
+// Backups returns the paths of the rotated files, oldest first, including
+// compressed ones.
+func (w *RotatingFile) Backups() ([]string, error) {
+	ext := filepath.Ext(w.Path)
+	base := strings.TrimSuffix(filepath.Base(w.Path), ext)
+	entries, err := os.ReadDir(filepath.Dir(w.Path))
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, e := range entries {
+		name := e.Name()
+		stamp := strings.TrimSuffix(name, ".gz")
+		if e.IsDir() || !strings.HasPrefix(stamp, base+"-") || !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		stamp = strings.TrimSuffix(strings.TrimPrefix(stamp, base+"-"), ext)
+		if _, err := time.ParseInLocation(backupTimeLayout, stamp, time.Local); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(filepath.Dir(w.Path), name))
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// record adds a completed backup to the manifest. Failures are ignored so
+// that rotation never stops logging.
+func (w *RotatingFile) record(path string) {
+	if w.Manifest != "" {
+		UpdateManifest(w.Manifest, path)
+	}
+}
+
+// prune deletes the backups MaxBackups and MaxAge do not keep. Failures
+// are ignored so that rotation never stops logging.
+func (w *RotatingFile) prune() {
+	if w.MaxBackups <= 0 && w.MaxAge <= 0 {
+		return
+	}
+	backups, err := w.Backups()
+	if err != nil {
+		return
+	}
+	for i, path := range backups {
+		expired := w.MaxBackups > 0 && i < len(backups)-w.MaxBackups
+		if !expired && w.MaxAge > 0 {
+			if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) > w.MaxAge {
+				expired = true
+			}
+		}
+		if expired {
+			os.Remove(path)
+			os.Remove(path + ".idx")
+		}
+	}
+}
+
+// Flush syncs the current file.
+func (w *RotatingFile) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	return w.f.Sync()
+}
+
+// Close closes the file and waits for backups being compressed.
+func (w *RotatingFile) Close() error {
+	w.mu.Lock()
+	var err error
+	if w.f != nil {
+		err = w.f.Close()
+		w.f = nil
+	}
+	w.idx.Close()
+	w.idx = nil
+	w.mu.Unlock()
+	w.wg.Wait()
+	return err
+}
+
+// compressFile replaces path with a gzipped path.gz. The original is only
+// removed once the compressed copy is synced and in place, so a failure
+// leaves it as it was.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = dst.Chmod(0644)
+	}
+	if err == nil {
+		err = dst.Sync()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(dst.Name(), path+".gz")
+	}
+	if err != nil {
+		os.Remove(dst.Name())
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
+
+// Reopen closes the file, which the next write opens again at its path.
+func (w *RotatingFile) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.idx.Close()
+	w.idx = nil
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
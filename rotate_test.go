@@ -0,0 +1,98 @@
+package consoleEx
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var rotateEvent = []byte(`{"level":"info","message":"0123456789012345678901234567890123456789"}` + "\n")
+
+func TestRotatingFileRollsAtMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	w := NewRotatingFile(path, int64(2*len(rotateEvent)))
+	for i := 0; i < 5; i++ {
+		if _, err := w.Write(rotateEvent); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+	backups, err := w.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("backups = %v, want two", backups)
+	}
+	for _, b := range backups {
+		fi, err := os.Stat(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Size() != int64(2*len(rotateEvent)) {
+			t.Fatalf("backup %s holds %d bytes, want two events", b, fi.Size())
+		}
+	}
+	if data, _ := os.ReadFile(path); len(data) != len(rotateEvent) {
+		t.Fatalf("current file holds %d bytes, want one event", len(data))
+	}
+}
+
+func TestRotatingFileKeepsMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	w := NewRotatingFile(filepath.Join(dir, "app.log"), int64(len(rotateEvent)))
+	w.MaxBackups = 2
+	for i := 0; i < 6; i++ {
+		w.Write(rotateEvent)
+	}
+	w.Close()
+	backups, _ := w.Backups()
+	if len(backups) != 2 {
+		t.Fatalf("backups = %v, want the newest two", backups)
+	}
+}
+
+func TestRotatingFileBackupsParsesStamps(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"app-20240601T101500.000.log",
+		"app-20240601T101501.250.log.gz",
+		"app-20240601T1015.log",
+		"app-worker.log",
+		"app-20240601T101500.000.txt",
+		"other-20240601T101500.000.log",
+	} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0666)
+	}
+	w := NewRotatingFile(filepath.Join(dir, "app.log"), 0)
+	backups, err := w.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "app-20240601T101500.000.log"),
+		filepath.Join(dir, "app-20240601T101501.250.log.gz"),
+	}
+	if !reflect.DeepEqual(backups, want) {
+		t.Fatalf("Backups = %v, want %v", backups, want)
+	}
+}
+
+func TestRotatingFileBackupNamesAreUnique(t *testing.T) {
+	dir := t.TempDir()
+	w := NewRotatingFile(filepath.Join(dir, "app.log"), 0)
+	w.Write(rotateEvent)
+	for i := 0; i < 3; i++ {
+		if err := w.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+		w.Write(rotateEvent)
+	}
+	w.Close()
+	backups, _ := w.Backups()
+	if len(backups) != 3 {
+		t.Fatalf("backups = %v, want three distinct names", backups)
+	}
+}
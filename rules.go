@@ -0,0 +1,45 @@
+package consoleEx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldRules keeps the default rendering minimal while still surfacing
+// anomalies: a field with a rule is only shown by ConsoleWriterEx when the
+// rule's predicate matches the event. Fields without a rule always show.
+type FieldRules map[string]Predicate
+
+// ParseFieldRules parses rules of the form "field: predicate", such as
+// "cache: hit=false", or a bare predicate such as "retry_count>0", which
+// applies to the field it tests.
+func ParseFieldRules(rules ...string) (FieldRules, error) {
+	r := make(FieldRules, len(rules))
+	for _, rule := range rules {
+		field, expr := "", rule
+		if i := strings.IndexByte(rule, ':'); i > 0 && !strings.ContainsAny(rule[:i], "!=<>~") {
+			field, expr = strings.TrimSpace(rule[:i]), strings.TrimSpace(rule[i+1:])
+		}
+		p, err := ParsePredicate(expr)
+		if err != nil {
+			return nil, fmt.Errorf("field rule %q: %w", rule, err)
+		}
+		if field == "" {
+			field = strings.TrimSpace(expr[:strings.IndexAny(expr, "!=<>~")])
+		}
+		r[field] = p
+	}
+	return r, nil
+}
+
+// filter returns the fields to render for event.
+func (r FieldRules) filter(event map[string]interface{}, fields []string) []string {
+	out := fields[:0]
+	for _, f := range fields {
+		if p, ok := r[f]; ok && !p(event) {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
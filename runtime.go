@@ -0,0 +1,140 @@
+package consoleEx
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+// RuntimeReporter periodically writes an event with Go runtime statistics
+// (goroutines, heap, GC counts and pauses) to Out, a poor man's metrics for
+// small services. Reporting can be switched on and off while it runs.
+type RuntimeReporter struct {
+	Out   io.Writer
+	Level Level
+
+	mu       sync.Mutex
+	enabled  bool
+	interval time.Duration
+	reset    chan struct{}
+	lastGC   uint32
+}
+
+// defaultRuntimeInterval replaces intervals that are not positive.
+const defaultRuntimeInterval = time.Minute
+
+// NewRuntimeReporter returns an enabled reporter writing debug events to
+// out every interval, or every minute if it is not positive, once Run is
+// called.
+func NewRuntimeReporter(out io.Writer, interval time.Duration) *RuntimeReporter {
+	return &RuntimeReporter{Out: out, Level: DebugLevel, enabled: true, interval: interval, reset: make(chan struct{}, 1)}
+}
+
+// period returns the reporting interval; r.mu must be held.
+func (r *RuntimeReporter) period() time.Duration {
+	if r.interval <= 0 {
+		return defaultRuntimeInterval
+	}
+	return r.interval
+}
+
+// SetEnabled switches reporting on or off without stopping Run.
+func (r *RuntimeReporter) SetEnabled(on bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.enabled = on
+}
+
+func (r *RuntimeReporter) Enabled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enabled
+}
+
+// SetInterval changes the reporting period, taking effect immediately. A
+// period that is not positive restores the default of one minute.
+func (r *RuntimeReporter) SetInterval(d time.Duration) {
+	r.mu.Lock()
+	r.interval = d
+	r.mu.Unlock()
+	select {
+	case r.reset <- struct{}{}:
+	default:
+	}
+}
+
+// Run reports until ctx is done.
+func (r *RuntimeReporter) Run(ctx context.Context) {
+	r.mu.Lock()
+	t := time.NewTicker(r.period())
+	r.mu.Unlock()
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if r.Enabled() {
+				r.Report()
+			}
+		case <-r.reset:
+			r.mu.Lock()
+			t.Reset(r.period())
+			r.mu.Unlock()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Start runs the reporter in the background until the returned stop
+// function is called.
+func (r *RuntimeReporter) Start() (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go r.Run(ctx)
+	return cancel
+}
+
+// Report writes one statistics event now. gc_pauses_ms lists the pauses of
+// the collections since the previous report, newest last.
+func (r *RuntimeReporter) Report() error {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	r.mu.Lock()
+	since := m.NumGC - r.lastGC
+	r.lastGC = m.NumGC
+	r.mu.Unlock()
+	if since > uint32(len(m.PauseNs)) {
+		since = uint32(len(m.PauseNs))
+	}
+	pauses := make([]float64, 0, since)
+	for i := since; i > 0; i-- {
+		ns := m.PauseNs[(m.NumGC-i)%uint32(len(m.PauseNs))]
+		pauses = append(pauses, float64(ns)/1e6)
+	}
+	b, err := json.Marshal(map[string]interface{}{
+		LevelFieldName:      r.Level.String(),
+		TimestampFieldName:  time.Now().Format(TimeFieldFormat),
+		MessageFieldName:    "runtime stats",
+		"goroutines":        runtime.NumGoroutine(),
+		"heap_alloc":        m.HeapAlloc,
+		"heap_sys":          m.HeapSys,
+		"heap_objects":      m.HeapObjects,
+		"num_gc":            m.NumGC,
+		"gc_pauses_ms":      pauses,
+		"gc_pause_total_ms": float64(m.PauseTotalNs) / 1e6,
+	})
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if lw, ok := r.Out.(LevelWriter); ok {
+		_, err = lw.WriteLevel(r.Level, b)
+	} else {
+		_, err = r.Out.Write(b)
+	}
+	return err
+}
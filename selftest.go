@@ -0,0 +1,177 @@
+package consoleEx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+// SelfChecker is implemented by sinks that can check their own health
+// beyond accepting a write, such as directory permissions or reachability.
+type SelfChecker interface {
+	SelfCheck(ctx context.Context) error
+}
+
+// CheckResult is the outcome of one SelfTest step.
+type CheckResult struct {
+	Sink     string
+	Check    string
+	Err      error
+	Duration time.Duration
+}
+
+// SelfTestReport collects the results of SelfTest.
+type SelfTestReport struct {
+	Results []CheckResult
+}
+
+// OK reports whether every check passed.
+func (r *SelfTestReport) OK() bool {
+	for _, c := range r.Results {
+		if c.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTo prints one line per check followed by a verdict.
+func (r *SelfTestReport) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	for _, c := range r.Results {
+		status, detail := "ok", ""
+		if c.Err != nil {
+			status, detail = "FAIL", c.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", status, c.Sink, c.Check, c.Duration.Round(time.Microsecond), detail)
+	}
+	tw.Flush()
+	if r.OK() {
+		b.WriteString("all checks passed\n")
+	} else {
+		b.WriteString("some checks failed\n")
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// selfTestLevels are the levels SelfTest writes; fatal and panic are left
+// out so sinks paging on them stay quiet.
+var selfTestLevels = []Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel}
+
+// SelfTest exercises sinks, or every writer passed to Register when sinks
+// is nil: it writes an event marked selftest=true at each level from trace
+// to error, flushes buffering sinks so delivery errors surface, and runs
+// SelfCheck where implemented. Files are also checked for a writable
+// directory, which rotation needs.
+func SelfTest(ctx context.Context, sinks map[string]io.Writer) *SelfTestReport {
+	if sinks == nil {
+		sinks = make(map[string]io.Writer)
+		shutdownRegistry.Lock()
+		for _, nw := range shutdownRegistry.writers {
+			sinks[nw.name] = nw.w
+		}
+		shutdownRegistry.Unlock()
+	}
+	names := make([]string, 0, len(sinks))
+	for name := range sinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	r := &SelfTestReport{}
+	for _, name := range names {
+		r.Results = append(r.Results, selfTestSink(ctx, name, sinks[name])...)
+	}
+	return r
+}
+
+func selfTestSink(ctx context.Context, name string, w io.Writer) []CheckResult {
+	var results []CheckResult
+	run := func(check string, f func() error) {
+		start := time.Now()
+		err := f()
+		results = append(results, CheckResult{name, check, err, time.Since(start)})
+	}
+	run("write", func() error {
+		for _, l := range selfTestLevels {
+			line := fmt.Sprintf(`{%q:%q,%q:%q,%q:"consoleEx self test","selftest":true}`+"\n",
+				LevelFieldName, l.String(), TimestampFieldName, time.Now().Format(TimeFieldFormat), MessageFieldName)
+			var err error
+			if lw, ok := w.(LevelWriter); ok {
+				_, err = lw.WriteLevel(l, []byte(line))
+			} else {
+				_, err = w.Write([]byte(line))
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", l, err)
+			}
+		}
+		return nil
+	})
+	if f, ok := w.(Flusher); ok {
+		run("flush", f.Flush)
+	}
+	if f, ok := w.(*os.File); ok && f != os.Stdout && f != os.Stderr {
+		run("directory", func() error { return checkDirWritable(filepath.Dir(f.Name())) })
+	}
+	if c, ok := w.(SelfChecker); ok {
+		run("self check", func() error { return c.SelfCheck(ctx) })
+	}
+	return results
+}
+
+// checkDirWritable verifies new files can be created in dir.
+func checkDirWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".consoleex-selftest-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// SelfCheck verifies the current partition directory can be created and
+// written.
+func (w *PartitionedFile) SelfCheck(ctx context.Context) error {
+	dir := filepath.Dir(w.PathAt(time.Now()))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return checkDirWritable(dir)
+}
+
+// SelfCheck dials Addr, through Proxy if set, to verify reachability.
+func (w *NetWriter) SelfCheck(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return errSinkClosed
+	}
+	if w.conn != nil {
+		return nil
+	}
+	return w.dial()
+}
+
+// SelfCheck verifies the spool directory is writable and reports batches
+// whose last upload failed.
+func (s *EdgeSpool) SelfCheck(ctx context.Context) error {
+	if err := checkDirWritable(s.Dir); err != nil {
+		return err
+	}
+	for _, b := range s.Pending() {
+		if b.LastError != "" {
+			return fmt.Errorf("batch %s: %d failed uploads, last: %s", b.Name, b.Attempts, b.LastError)
+		}
+	}
+	return nil
+}
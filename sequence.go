@@ -0,0 +1,129 @@
+package consoleEx
+
+import (
+	"crypto/rand"
+	"io"
+	"sync"
+	"time"
+)
+
+// SeqFieldName and IDFieldName are the fields a Sequencer adds.
+var (
+	SeqFieldName = "seq"
+	IDFieldName  = "event_id"
+)
+
+// Sequencer stamps every event with a sequence number, starting at 1 and
+// increasing without gaps, and a ULID, then writes it to Next. Place it
+// before any fan-out or retrying sink so every copy and every retry of an
+// event carries the same stamps, letting consumers drop duplicates and spot
+// lost events.
+type Sequencer struct {
+	Next io.Writer
+	// NoID disables the event ID, keeping only the sequence number.
+	NoID bool
+
+	mu  sync.Mutex
+	seq uint64
+	ids ulidSource
+}
+
+func NewSequencer(next io.Writer) *Sequencer {
+	return &Sequencer{Next: next}
+}
+
+// Sequence returns a Decorator stamping events like a Sequencer.
+func Sequence() Decorator {
+	return func(next io.Writer) io.Writer {
+		return NewSequencer(next)
+	}
+}
+
+// Write holds the lock while writing so events reach Next in sequence
+// order. Writes that are not JSON objects pass unstamped, and a number is
+// only used up once Next accepted the event.
+func (s *Sequencer) Write(p []byte) (int, error) {
+	if !isJSONObject(p) {
+		return s.Next.Write(p)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q := injectField(p, SeqFieldName, s.seq+1)
+	if !s.NoID {
+		q = injectField(q, IDFieldName, s.ids.next(time.Now()))
+	}
+	if _, err := s.Next.Write(q); err != nil {
+		return 0, err
+	}
+	s.seq++
+	return len(p), nil
+}
+
+// Seq returns the last sequence number assigned.
+func (s *Sequencer) Seq() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seq
+}
+
+var eventIDs struct {
+	sync.Mutex
+	src ulidSource
+}
+
+// NewEventID returns a new ULID: 26 characters that sort by creation time,
+// monotonic within this process.
+func NewEventID() string {
+	eventIDs.Lock()
+	defer eventIDs.Unlock()
+	return eventIDs.src.next(time.Now())
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidSource generates monotonic ULIDs: within the same millisecond the
+// random part of the previous ID is incremented instead of redrawn.
+type ulidSource struct {
+	ms      uint64
+	entropy [10]byte
+}
+
+func (u *ulidSource) next(t time.Time) string {
+	ms := uint64(t.UnixMilli())
+	if ms <= u.ms {
+		ms = u.ms
+		for i := len(u.entropy) - 1; i >= 0; i-- {
+			u.entropy[i]++
+			if u.entropy[i] != 0 {
+				break
+			}
+		}
+	} else {
+		u.ms = ms
+		rand.Read(u.entropy[:])
+	}
+	var id [16]byte
+	for i := 0; i < 6; i++ {
+		id[i] = byte(ms >> (40 - 8*i))
+	}
+	copy(id[6:], u.entropy[:])
+	return encodeULID(id)
+}
+
+// encodeULID writes the 128 bit id as 26 Crockford base32 digits, the first
+// of which holds only 3 bits.
+func encodeULID(id [16]byte) string {
+	var out [26]byte
+	out[0] = crockford[id[0]>>5]
+	acc, bits, pos := uint64(id[0]&0x1f), 5, 1
+	for _, b := range id[1:] {
+		acc = acc<<8 | uint64(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			out[pos] = crockford[(acc>>uint(bits))&0x1f]
+			pos++
+		}
+	}
+	return string(out[:])
+}
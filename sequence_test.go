@@ -0,0 +1,40 @@
+package consoleEx
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type flakyWriter struct {
+	fail bool
+	buf  bytes.Buffer
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	if w.fail {
+		return 0, errors.New("unavailable")
+	}
+	return w.buf.Write(p)
+}
+
+func TestSequencerNoGaps(t *testing.T) {
+	next := &flakyWriter{}
+	s := &Sequencer{Next: next, NoID: true}
+	s.Write([]byte("plain text\n"))
+	s.Write([]byte(`{"message":"one"}` + "\n"))
+	next.fail = true
+	if _, err := s.Write([]byte(`{"message":"lost"}` + "\n")); err == nil {
+		t.Fatal("want the error of Next")
+	}
+	next.fail = false
+	s.Write([]byte(`{"message":"two","seq":99}` + "\n"))
+	want := "plain text\n" + `{"message":"one","seq":1}` + "\n" + `{"message":"two","seq":2}` + "\n"
+	if got := next.buf.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if strings.Count(next.buf.String(), `"seq"`) != 2 || s.Seq() != 2 {
+		t.Fatalf("Seq() = %d, want 2", s.Seq())
+	}
+}
@@ -0,0 +1,223 @@
+package consoleEx
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ShardedWriter buffers events in independently locked shards so that
+// many concurrent producers rarely contend on a lock, and merges the
+// shards into Next from a background flusher.
+//
+// Ordering: events are dealt to the shards round-robin and numbered as
+// they are, and every flush writes what all shards hold in that order.
+// Events therefore reach Next in the order of their Writes wherever those
+// are ordered, as the writes of one goroutine are; only Writes running
+// concurrently may land in either order. Every event reaches Next whole,
+// in a Write of its own. Fatal and panic events are written and flushed
+// at once, after everything buffered before them.
+type ShardedWriter struct {
+	next   io.Writer
+	seq    uint64
+	mu     sync.Mutex
+	shards []writeShard
+	limit  int
+	closed int32
+	done   chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+
+	// batch, events and slots hold a flush being written, under mu.
+	batch  []byte
+	events []shardEvent
+	slots  []shardEvent
+}
+
+type writeShard struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	events []shardEvent
+	_      [64]byte
+}
+
+// shardEvent locates an event by its number and where it ends in a
+// shard's buffer or a flush batch.
+type shardEvent struct {
+	seq        uint64
+	start, end int
+}
+
+// NewShardedWriter returns a ShardedWriter with n shards, flushed every
+// interval and whenever a shard holds more than 64KiB. n defaults to 8.
+func NewShardedWriter(next io.Writer, n int, interval time.Duration) *ShardedWriter {
+	if n <= 0 {
+		n = 8
+	}
+	if interval <= 0 {
+		interval = 100 * time.Millisecond
+	}
+	w := &ShardedWriter{
+		next:   next,
+		shards: make([]writeShard, n),
+		limit:  64 * 1024,
+		done:   make(chan struct{}),
+	}
+	w.wg.Add(1)
+	go w.loop(interval)
+	return w
+}
+
+func (w *ShardedWriter) loop(interval time.Duration) {
+	defer w.wg.Done()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			w.Flush()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *ShardedWriter) Write(p []byte) (int, error) {
+	if atomic.LoadInt32(&w.closed) != 0 {
+		return 0, errSinkClosed
+	}
+	if IsPriority(p) {
+		return w.writePriority(p)
+	}
+	seq := atomic.AddUint64(&w.seq, 1)
+	s := &w.shards[seq%uint64(len(w.shards))]
+	s.mu.Lock()
+	if atomic.LoadInt32(&w.closed) != 0 {
+		s.mu.Unlock()
+		return 0, errSinkClosed
+	}
+	start := s.buf.Len()
+	s.buf.Write(p)
+	s.events = append(s.events, shardEvent{seq, start, s.buf.Len()})
+	full := s.buf.Len() >= w.limit
+	s.mu.Unlock()
+	if full {
+		if err := w.Flush(); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// writePriority writes p straight to next after the buffered events and
+// flushes next.
+func (w *ShardedWriter) writePriority(p []byte) (int, error) {
+	err := w.Flush()
+	w.mu.Lock()
+	_, werr := w.next.Write(p)
+	w.mu.Unlock()
+	if werr != nil {
+		return 0, werr
+	}
+	if f, ok := w.next.(Flusher); ok {
+		if ferr := f.Flush(); err == nil {
+			err = ferr
+		}
+	}
+	return len(p), err
+}
+
+// Flush writes out the events of every shard in the order they were
+// numbered.
+func (w *ShardedWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.batch, w.events = w.batch[:0], w.events[:0]
+	for i := range w.shards {
+		s := &w.shards[i]
+		s.mu.Lock()
+		off := len(w.batch)
+		w.batch = append(w.batch, s.buf.Bytes()...)
+		for _, e := range s.events {
+			w.events = append(w.events, shardEvent{e.seq, off + e.start, off + e.end})
+		}
+		s.buf.Reset()
+		s.events = s.events[:0]
+		s.mu.Unlock()
+	}
+	w.order()
+	var err error
+	for _, e := range w.events {
+		if _, werr := w.next.Write(w.batch[e.start:e.end]); err == nil {
+			err = werr
+		}
+	}
+	return err
+}
+
+// order sorts the events of a flush by number. The numbers are nearly
+// dense, so they are placed by offset from the lowest in linear time,
+// falling back to sorting when a stalled Write left a wide gap.
+func (w *ShardedWriter) order() {
+	if len(w.events) < 2 {
+		return
+	}
+	lo, hi := w.events[0].seq, w.events[0].seq
+	for _, e := range w.events {
+		if e.seq < lo {
+			lo = e.seq
+		}
+		if e.seq > hi {
+			hi = e.seq
+		}
+	}
+	span := hi - lo + 1
+	if span > uint64(4*len(w.events)) {
+		sort.Slice(w.events, func(i, j int) bool { return w.events[i].seq < w.events[j].seq })
+		return
+	}
+	if uint64(cap(w.slots)) < span {
+		w.slots = make([]shardEvent, span)
+	}
+	slots := w.slots[:span]
+	for i := range slots {
+		slots[i].seq = 0
+	}
+	for _, e := range w.events {
+		slots[e.seq-lo] = e
+	}
+	events := w.events[:0]
+	for _, e := range slots {
+		if e.seq != 0 {
+			events = append(events, e)
+		}
+	}
+	w.events = events
+}
+
+// Close stops the flusher and flushes the remaining events; later writes
+// fail. Next is not closed.
+func (w *ShardedWriter) Close() error {
+	w.once.Do(func() {
+		atomic.StoreInt32(&w.closed, 1)
+		close(w.done)
+		w.wg.Wait()
+	})
+	return w.Flush()
+}
+
+// Pressure reports the buffered bytes against the flush threshold of all
+// shards.
+func (w *ShardedWriter) Pressure() Pressure {
+	queued := 0
+	for i := range w.shards {
+		s := &w.shards[i]
+		s.mu.Lock()
+		queued += s.buf.Len()
+		s.mu.Unlock()
+	}
+	return newPressure(queued, w.limit*len(w.shards), 0)
+}
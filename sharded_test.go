@@ -0,0 +1,88 @@
+package consoleEx
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShardedWriterFlushesEventsSingly(t *testing.T) {
+	var out bytes.Buffer
+	w := NewShardedWriter(ConsoleWriterEx{Out: &out, NoColor: true}, 1, time.Hour)
+	w.Write([]byte(`{"message":"one"}` + "\n"))
+	w.Write([]byte(`{"message":"two"}` + "\n"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, "one") || !strings.Contains(got, "two") {
+		t.Fatalf("got %q, want both events", got)
+	}
+	if _, err := w.Write([]byte(`{"message":"three"}` + "\n")); err != errSinkClosed {
+		t.Fatalf("Write after Close: got %v, want %v", err, errSinkClosed)
+	}
+}
+
+type lineRecorder struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (r *lineRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	r.lines = append(r.lines, string(p))
+	r.mu.Unlock()
+	return len(p), nil
+}
+
+func TestShardedWriterKeepsGoroutineOrder(t *testing.T) {
+	r := &lineRecorder{}
+	w := NewShardedWriter(r, 4, time.Millisecond)
+	const producers, events = 8, 1000
+	var wg sync.WaitGroup
+	for g := 0; g < producers; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < events; i++ {
+				fmt.Fprintf(w, "%d %d\n", g, i)
+			}
+		}(g)
+	}
+	wg.Wait()
+	w.Close()
+	if len(r.lines) != producers*events {
+		t.Fatalf("got %d events, want %d", len(r.lines), producers*events)
+	}
+	next := make(map[int]int)
+	for _, l := range r.lines {
+		var g, i int
+		if _, err := fmt.Sscanf(l, "%d %d", &g, &i); err != nil {
+			t.Fatalf("event %q: %v", l, err)
+		}
+		if i != next[g] {
+			t.Fatalf("goroutine %d: got event %d, want %d", g, i, next[g])
+		}
+		next[g]++
+	}
+}
+
+func TestShardedWriterOrderAcrossFullShards(t *testing.T) {
+	r := &lineRecorder{}
+	w := NewShardedWriter(r, 4, time.Hour)
+	w.limit = 16
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(w, "event %d\n", i)
+	}
+	w.Close()
+	for i, l := range r.lines {
+		if want := fmt.Sprintf("event %d\n", i); l != want {
+			t.Fatalf("line %d = %q, want %q", i, l, want)
+		}
+	}
+	if len(r.lines) != 100 {
+		t.Fatalf("got %d events, want 100", len(r.lines))
+	}
+}
@@ -0,0 +1,82 @@
+package consoleEx
+
+import (
+	"bytes"
+	"os"
+	"sync"
+)
+
+// SharedFile appends to a log file written by several processes at once.
+// Partial writes are held back until their line is complete, and every
+// batch of complete lines goes out in a single write on an O_APPEND
+// descriptor, which local filesystems keep contiguous. With Lock set, each
+// write also holds an exclusive advisory lock (flock) on the file for
+// filesystems where appends alone are not atomic, such as NFS; the lock
+// is a no-op on platforms without flock.
+type SharedFile struct {
+	Lock bool
+
+	mu      sync.Mutex
+	f       *os.File
+	partial []byte
+}
+
+func OpenSharedFile(path string, lock bool) (*SharedFile, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, err
+	}
+	return &SharedFile{Lock: lock, f: f}, nil
+}
+
+func (s *SharedFile) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return 0, errSinkClosed
+	}
+	data := p
+	if len(s.partial) > 0 {
+		data = append(s.partial, p...)
+	}
+	i := bytes.LastIndexByte(data, '\n')
+	if i < 0 {
+		s.partial = append(s.partial[:0], data...)
+		return len(p), nil
+	}
+	if err := s.writeLines(data[:i+1]); err != nil {
+		return 0, err
+	}
+	s.partial = append(s.partial[:0], data[i+1:]...)
+	return len(p), nil
+}
+
+func (s *SharedFile) writeLines(b []byte) error {
+	if s.Lock {
+		if err := lockFile(s.f); err != nil {
+			return err
+		}
+		defer unlockFile(s.f)
+	}
+	_, err := s.f.Write(b)
+	return err
+}
+
+// Close writes out an unterminated last line, if any, and closes the file.
+func (s *SharedFile) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	var err error
+	if len(s.partial) > 0 {
+		err = s.writeLines(append(s.partial, '\n'))
+		s.partial = nil
+	}
+	if cerr := s.f.Close(); err == nil {
+		err = cerr
+	}
+	s.f = nil
+	return err
+}
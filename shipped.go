@@ -0,0 +1,31 @@
+package consoleEx
+
+import (
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+// ShippedAtFieldName is the field network sinks add, when asked to, with
+// the time an event actually left the process. Next to the producer's
+// TimestampFieldName it shows how long events spend in the pipeline.
+var ShippedAtFieldName = "shipped_at"
+
+// stampShipped adds ShippedAtFieldName to p, formatted like zerolog
+// timestamps.
+func stampShipped(p []byte, t time.Time) []byte {
+	var v interface{}
+	switch TimeFieldFormat {
+	case TimeFormatUnix:
+		v = t.Unix()
+	case TimeFormatUnixMs:
+		v = t.UnixMilli()
+	case TimeFormatUnixMicro:
+		v = t.UnixMicro()
+	case TimeFormatUnixNano:
+		v = t.UnixNano()
+	default:
+		v = t.Format(TimeFieldFormat)
+	}
+	return injectField(p, ShippedAtFieldName, v)
+}
@@ -0,0 +1,122 @@
+package consoleEx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Flusher is implemented by sinks that buffer events.
+type Flusher interface {
+	Flush() error
+}
+
+type namedWriter struct {
+	name string
+	w    io.Writer
+}
+
+var shutdownRegistry struct {
+	sync.Mutex
+	writers []namedWriter
+}
+
+// Register adds w to the writers drained by Shutdown. name identifies it
+// in the returned ShutdownError.
+func Register(name string, w io.Writer) {
+	shutdownRegistry.Lock()
+	defer shutdownRegistry.Unlock()
+	shutdownRegistry.writers = append(shutdownRegistry.writers, namedWriter{name, w})
+}
+
+// Unregister removes w, e.g. after closing it by hand.
+func Unregister(w io.Writer) {
+	shutdownRegistry.Lock()
+	defer shutdownRegistry.Unlock()
+	ws := shutdownRegistry.writers[:0]
+	for _, nw := range shutdownRegistry.writers {
+		if nw.w != w {
+			ws = append(ws, nw)
+		}
+	}
+	shutdownRegistry.writers = ws
+}
+
+// ShutdownError reports the sinks that failed to flush or close, or did
+// not finish before the deadline.
+type ShutdownError struct {
+	Failed map[string]error
+}
+
+func (e *ShutdownError) Error() string {
+	names := make([]string, 0, len(e.Failed))
+	for name := range e.Failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s: %v", name, e.Failed[name])
+	}
+	return "consoleEx: shutdown: " + strings.Join(parts, "; ")
+}
+
+// Shutdown flushes and closes every registered writer concurrently, giving
+// up on the ones still draining when ctx is done. The registry is empty
+// afterwards. The error, if any, is a *ShutdownError.
+func Shutdown(ctx context.Context) error {
+	shutdownRegistry.Lock()
+	writers := shutdownRegistry.writers
+	shutdownRegistry.writers = nil
+	shutdownRegistry.Unlock()
+
+	type result struct {
+		name string
+		err  error
+	}
+	results := make(chan result, len(writers))
+	for _, nw := range writers {
+		go func(nw namedWriter) {
+			results <- result{nw.name, drain(nw.w)}
+		}(nw)
+	}
+	failed := make(map[string]error)
+	pending := make(map[string]bool, len(writers))
+	for _, nw := range writers {
+		pending[nw.name] = true
+	}
+	for range writers {
+		select {
+		case r := <-results:
+			delete(pending, r.name)
+			if r.err != nil {
+				failed[r.name] = r.err
+			}
+		case <-ctx.Done():
+			for name := range pending {
+				failed[name] = ctx.Err()
+			}
+			return &ShutdownError{Failed: failed}
+		}
+	}
+	if len(failed) > 0 {
+		return &ShutdownError{Failed: failed}
+	}
+	return nil
+}
+
+func drain(w io.Writer) error {
+	var err error
+	if f, ok := w.(Flusher); ok {
+		err = f.Flush()
+	}
+	if c, ok := w.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,374 @@
+package consoleEx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	. "github.com/rs/zerolog"
+)
+
+// SinkFactory opens the sink described by a parsed URI. Sinks running
+// background work or holding connections should stop when ctx is done.
+type SinkFactory func(ctx context.Context, u *url.URL) (io.WriteCloser, error)
+
+var sinkRegistry = struct {
+	sync.RWMutex
+	m map[string]SinkFactory
+}{m: make(map[string]SinkFactory)}
+
+// RegisterSink makes a URI scheme available to OpenSink, replacing any
+// factory registered for it before.
+func RegisterSink(scheme string, f SinkFactory) {
+	sinkRegistry.Lock()
+	defer sinkRegistry.Unlock()
+	sinkRegistry.m[scheme] = f
+}
+
+// OpenSink opens a sink from a URI such as "file:///var/log/app.log",
+// "stdout://", "tcp://collector:514" or any registered scheme. File sinks
+// accept ?compliance=pci or another CompliancePreset name and
+// ?rotate=100MB&backups=5 for rotation. The format
+// query parameter (json, text, logfmt, csv, html) selects how events are
+// rendered, defaulting to json for files and networks and text for the
+// standard streams. "exec:///usr/local/bin/sink?arg=-v" runs a command
+// fed events on stdin, see ExecSink; with the consoleex_plugin build tag,
+// "plugin:///path/sink.so" opens a Go plugin exporting NewSink.
+func OpenSink(uri string) (io.WriteCloser, error) {
+	return OpenSinkContext(context.Background(), uri)
+}
+
+// OpenSinkContext is OpenSink with a context bounding the sink's lifetime.
+func OpenSinkContext(ctx context.Context, uri string) (io.WriteCloser, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	sinkRegistry.RLock()
+	f, ok := sinkRegistry.m[u.Scheme]
+	sinkRegistry.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("consoleEx: unknown sink scheme %q", u.Scheme)
+	}
+	return f(ctx, u)
+}
+
+func init() {
+	RegisterSink("file", openFileSink)
+	RegisterSink("stdout", func(ctx context.Context, u *url.URL) (io.WriteCloser, error) {
+		return formatSink(u, "text", true, nopCloser{stdout()})
+	})
+	RegisterSink("stderr", func(ctx context.Context, u *url.URL) (io.WriteCloser, error) {
+		return formatSink(u, "text", true, nopCloser{stderr()})
+	})
+	RegisterSink("exec", openExecSink)
+	for _, scheme := range []string{"http", "https"} {
+		RegisterSink(scheme, openHTTPSink)
+	}
+	for _, scheme := range []string{"tcp", "udp", "tls"} {
+		scheme := scheme
+		RegisterSink(scheme, func(ctx context.Context, u *url.URL) (io.WriteCloser, error) {
+			if u.Host == "" {
+				return nil, fmt.Errorf("consoleEx: %s sink needs host:port", scheme)
+			}
+			network := scheme
+			if scheme == "tls" {
+				network = "tcp"
+			}
+			cfg, err := sinkTLS(u, scheme == "tls")
+			if err != nil {
+				return nil, err
+			}
+			w := NewNetWriterContext(ctx, network, u.Host)
+			w.TLS = cfg
+			if w.Proxy, w.BandwidthLimit, err = sinkShipping(u); err != nil {
+				return nil, err
+			}
+			w.StampShipped = u.Query().Get("shipped") == "1"
+			return formatSink(u, "json", false, w)
+		})
+	}
+}
+
+func sinkPath(u *url.URL) string {
+	if u.Opaque != "" {
+		return u.Opaque
+	}
+	return filepath.FromSlash(u.Host + u.Path)
+}
+
+// fileSinkParams are the query parameters file sinks accept.
+var fileSinkParams = map[string]bool{"compliance": true, "codec": true, "format": true, "rotate": true, "backups": true}
+
+// openFileSink appends to the file at the URI's path. rotate=100MB makes
+// it a RotatingFile rolling at that size and keeping backups=N backups,
+// every one if unset; unknown parameters are rejected.
+func openFileSink(ctx context.Context, u *url.URL) (io.WriteCloser, error) {
+	name := sinkPath(u)
+	if name == "" {
+		return nil, fmt.Errorf("consoleEx: file sink needs a path")
+	}
+	q := u.Query()
+	for k := range q {
+		if !fileSinkParams[k] {
+			return nil, fmt.Errorf("consoleEx: unknown file sink parameter %q", k)
+		}
+	}
+	var rotate int64
+	if s := q.Get("rotate"); s != "" {
+		n, err := parseSize(s)
+		if err != nil {
+			return nil, err
+		}
+		if n <= 0 {
+			return nil, fmt.Errorf("consoleEx: rotate needs a positive size")
+		}
+		rotate = n
+	}
+	backups := 0
+	if s := q.Get("backups"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("consoleEx: invalid backups %q", s)
+		}
+		backups = n
+	}
+	if q.Get("backups") != "" && (rotate == 0 || q.Get("compliance") != "") {
+		return nil, fmt.Errorf("consoleEx: backups needs rotate and no compliance preset")
+	}
+	if rotate > 0 && q.Get("codec") != "" {
+		return nil, fmt.Errorf("consoleEx: codec cannot be combined with rotate")
+	}
+	if preset := q.Get("compliance"); preset != "" {
+		p, ok := LookupCompliancePreset(preset)
+		if !ok {
+			return nil, fmt.Errorf("consoleEx: unknown compliance preset %q", preset)
+		}
+		if q.Get("codec") != "" {
+			return nil, fmt.Errorf("consoleEx: codec cannot be combined with compliance")
+		}
+		if rotate > 0 {
+			p.MaxSize = rotate
+		}
+		w, err := p.OpenFile(name)
+		if err != nil {
+			return nil, err
+		}
+		return formatSink(u, "json", false, w)
+	}
+	if rotate > 0 {
+		f := &RotatingFile{Path: name, MaxSize: rotate, MaxBackups: backups, SyncOnFatal: true}
+		if err := f.open(); err != nil {
+			return nil, err
+		}
+		return formatSink(u, "json", false, f)
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, err
+	}
+	if c, err := sinkCodec(u); err != nil {
+		f.Close()
+		return nil, err
+	} else if c != nil {
+		zw := c.NewWriter(f)
+		return formatSink(u, "json", false, multiCloser{zw, []io.Closer{zw, f}})
+	}
+	return formatSink(u, "json", false, f)
+}
+
+func openExecSink(ctx context.Context, u *url.URL) (io.WriteCloser, error) {
+	name := sinkPath(u)
+	if name == "" {
+		return nil, fmt.Errorf("consoleEx: exec sink needs a command")
+	}
+	return formatSink(u, "json", false, NewExecSinkContext(ctx, name, u.Query()["arg"]...))
+}
+
+// openHTTPSink posts batches to the URI with the consoleEx specific query
+// parameters (codec, format, proxy, bandwidth, deadletter, shipped and the
+// TLS settings) removed. deadletter names a file receiving rejected events,
+// shipped=1 stamps events with their send time.
+func openHTTPSink(ctx context.Context, u *url.URL) (io.WriteCloser, error) {
+	c, err := sinkCodec(u)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := sinkTLS(u, false)
+	if err != nil {
+		return nil, err
+	}
+	target := *u
+	q := target.Query()
+	proxy, limit, err := sinkShipping(u)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range append([]string{"codec", "format", "proxy", "bandwidth", "deadletter", "shipped"}, tlsParams...) {
+		q.Del(p)
+	}
+	target.RawQuery = q.Encode()
+	var dl *DeadLetterFile
+	if path := u.Query().Get("deadletter"); path != "" {
+		if dl, err = OpenDeadLetterFile(path); err != nil {
+			return nil, err
+		}
+	}
+	w := NewHTTPWriter(ctx, target.String())
+	w.Codec = c
+	w.DeadLetter = dl
+	w.StampShipped = u.Query().Get("shipped") == "1"
+	w.Proxy = proxy
+	w.BandwidthLimit = limit
+	if cfg != nil {
+		p := http.ProxyFromEnvironment
+		if proxy != nil {
+			p = http.ProxyURL(proxy)
+		}
+		w.Client = &http.Client{Timeout: defaultHTTPTimeout, Transport: &http.Transport{Proxy: p, TLSClientConfig: cfg}}
+	}
+	var sink io.WriteCloser = w
+	if dl != nil {
+		sink = multiCloser{w, []io.Closer{w, dl}}
+	}
+	if e, ok := LookupEncoder(u.Query().Get("format")); ok {
+		w.Encoder = e
+		return sink, nil
+	}
+	return formatSink(u, "json", false, sink)
+}
+
+// sinkShipping reads the proxy URL and the bandwidth cap, such as
+// "?proxy=socks5://gw:1080&bandwidth=64KB", from a network sink URI.
+func sinkShipping(u *url.URL) (*url.URL, int64, error) {
+	q := u.Query()
+	var proxy *url.URL
+	if s := q.Get("proxy"); s != "" {
+		p, err := url.Parse(s)
+		if err != nil {
+			return nil, 0, err
+		}
+		proxy = p
+	}
+	var limit int64
+	if s := q.Get("bandwidth"); s != "" {
+		n, err := parseSize(s)
+		if err != nil {
+			return nil, 0, err
+		}
+		limit = n
+	}
+	return proxy, limit, nil
+}
+
+func sinkCodec(u *url.URL) (Codec, error) {
+	name := u.Query().Get("codec")
+	if name == "" {
+		return nil, nil
+	}
+	c, ok := LookupCodec(name)
+	if !ok {
+		return nil, fmt.Errorf("consoleEx: unknown codec %q", name)
+	}
+	return c, nil
+}
+
+// formatSink renders events in the format requested by u before they
+// reach w, colored text only if color is set. Closing the result closes w.
+func formatSink(u *url.URL, def string, color bool, w io.WriteCloser) (io.WriteCloser, error) {
+	format := u.Query().Get("format")
+	if format == "" {
+		format = def
+	}
+	f, err := NewFormatWriter(format, w)
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+	if format == "json" {
+		return w, nil
+	}
+	if format == "text" && color {
+		f = nopCloser{ConsoleWriterEx{Out: w}}
+	}
+	return multiCloser{f, []io.Closer{f, w}}, nil
+}
+
+type multiCloser struct {
+	io.Writer
+	closers []io.Closer
+}
+
+// WriteLevel passes level on if the wrapped writer is a LevelWriter.
+func (m multiCloser) WriteLevel(level Level, p []byte) (int, error) {
+	if lw, ok := m.Writer.(LevelWriter); ok {
+		return lw.WriteLevel(level, p)
+	}
+	return m.Writer.Write(p)
+}
+
+// Flush flushes the wrapped writers that buffer, so Shutdown and SelfTest
+// still reach them.
+func (m multiCloser) Flush() error {
+	var err error
+	for _, c := range m.closers {
+		if f, ok := c.(Flusher); ok {
+			if ferr := f.Flush(); ferr != nil && err == nil {
+				err = ferr
+			}
+		}
+	}
+	return err
+}
+
+// Reopen reopens the wrapped files, see Reopener.
+func (m multiCloser) Reopen() error {
+	var err error
+	for _, c := range m.closers {
+		if r, ok := c.(Reopener); ok {
+			if rerr := r.Reopen(); rerr != nil && err == nil {
+				err = rerr
+			}
+		}
+	}
+	return err
+}
+
+func (m multiCloser) SelfCheck(ctx context.Context) error {
+	for _, c := range m.closers {
+		if sc, ok := c.(SelfChecker); ok {
+			if err := sc.SelfCheck(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m multiCloser) Pressure() Pressure {
+	for _, c := range m.closers {
+		if r, ok := c.(PressureReporter); ok {
+			return r.Pressure()
+		}
+	}
+	return Pressure{}
+}
+
+func (m multiCloser) Close() error {
+	var err error
+	for _, c := range m.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
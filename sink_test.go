@@ -0,0 +1,46 @@
+package consoleEx
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSinkRotate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	w, err := OpenSink("file://" + filepath.ToSlash(path) + "?rotate=64B&backups=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := []byte(`{"level":"info","message":"0123456789012345678901234567890123456789"}` + "\n")
+	for i := 0; i < 4; i++ {
+		if _, err := w.Write(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+	backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v, want the newest one kept", backups)
+	}
+}
+
+func TestFileSinkRejectsParams(t *testing.T) {
+	dir := filepath.ToSlash(t.TempDir())
+	for uri, want := range map[string]string{
+		"file://" + dir + "/a.log?rotat=100MB":              "unknown file sink parameter",
+		"file://" + dir + "/a.log?rotate=100MB&codec=gzip":  "codec cannot be combined",
+		"file://" + dir + "/a.log?rotate=lots":              "invalid size",
+		"file://" + dir + "/a.log?backups=3":                "backups needs rotate",
+		"file://" + dir + "/a.log?compliance=pci&backups=3": "backups needs rotate",
+	} {
+		w, err := OpenSink(uri)
+		if err == nil {
+			w.Close()
+			t.Errorf("OpenSink(%q) succeeded", uri)
+		} else if !strings.Contains(err.Error(), want) {
+			t.Errorf("OpenSink(%q) = %v, want %q", uri, err, want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package consoleEx
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+
+	. "github.com/rs/zerolog"
+)
+
+// StackFilter makes ConsoleWriterEx render ErrorStackFieldName as a trace
+// below the event, one frame per line, instead of as a JSON field.
+type StackFilter struct {
+	// Skip lists prefixes of function names or source files whose frames
+	// are left out, such as "runtime.", "ServeHTTP" or "middleware.go".
+	// Frames in the pkg/errors format carry the function without its
+	// package; a source with directories is matched by its base name too.
+	Skip []string
+	// Fold collapses runs of identical frames, as left by recursion.
+	Fold bool
+}
+
+type stackFrame struct {
+	fn, source, line string
+}
+
+func (f *StackFilter) skip(fr stackFrame) bool {
+	for _, s := range f.Skip {
+		if strings.HasPrefix(fr.fn, s) || strings.HasPrefix(fr.source, s) ||
+			strings.HasPrefix(path.Base(fr.source), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// stackFrames decodes a pkg/errors style stack field.
+func stackFrames(v interface{}) ([]stackFrame, bool) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	frames := make([]stackFrame, 0, len(list))
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		var fr stackFrame
+		if s, ok := m["func"]; ok {
+			fr.fn = valueString(s)
+		}
+		if s, ok := m["source"]; ok {
+			fr.source = valueString(s)
+		}
+		if s, ok := m["line"]; ok {
+			fr.line = valueString(s)
+		}
+		frames = append(frames, fr)
+	}
+	return frames, true
+}
+
+// writeStack renders frames indented on their own lines, skipped frames
+// counted at the end.
+func (w ConsoleWriterEx) writeStack(buf *bytes.Buffer, frames []stackFrame) {
+	skipped := 0
+	for i := 0; i < len(frames); i++ {
+		fr := frames[i]
+		if w.Stack.skip(fr) {
+			skipped++
+			continue
+		}
+		repeat := 1
+		if w.Stack.Fold {
+			for i+1 < len(frames) && frames[i+1] == fr {
+				i++
+				repeat++
+			}
+		}
+		source := fr.source
+		if w.TrimPaths {
+			source = trimPath(source)
+		}
+		fmt.Fprintf(buf, "\n    at %s %s", colorize(fr.fn, cBlue, w.colors()),
+			colorize(source+":"+fr.line, cDarkGray, w.colors()))
+		if repeat > 1 {
+			fmt.Fprintf(buf, " (x%d)", repeat)
+		}
+	}
+	if skipped > 0 {
+		fmt.Fprintf(buf, "\n    %s", colorize(fmt.Sprintf("(%d frames skipped)", skipped), cDarkGray, w.colors()))
+	}
+}
+
+// takeStack removes the stack field from fields when it is rendered as a
+// trace, returning its frames.
+func (w ConsoleWriterEx) takeStack(event map[string]interface{}, fields []string) ([]string, []stackFrame) {
+	if w.Stack == nil || !containsString(fields, ErrorStackFieldName) {
+		return fields, nil
+	}
+	frames, ok := stackFrames(event[ErrorStackFieldName])
+	if !ok {
+		return fields, nil
+	}
+	return withoutField(fields, ErrorStackFieldName), frames
+}
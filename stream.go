@@ -0,0 +1,155 @@
+package consoleEx
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	. "github.com/rs/zerolog"
+)
+
+// StreamHandler is a sink broadcasting events to HTTP clients as
+// server-sent events. Each connection picks its own minimum level with
+// ?level=warn and its payload with ?format=json (the raw event, default)
+// or ?format=text (rendered, add &color=1 for ANSI colors).
+type StreamHandler struct {
+	// Buffer is the queue length per connection, events for connections
+	// that fall further behind are dropped. Defaults to 256.
+	Buffer int
+
+	mu      sync.Mutex
+	clients map[*streamClient]struct{}
+	dropped uint64
+}
+
+type streamClient struct {
+	min    Level
+	format string
+	color  bool
+	ch     chan []byte
+}
+
+func NewStreamHandler() *StreamHandler {
+	return &StreamHandler{clients: make(map[*streamClient]struct{})}
+}
+
+// Dropped returns the number of events dropped for slow connections.
+func (h *StreamHandler) Dropped() uint64 {
+	return atomic.LoadUint64(&h.dropped)
+}
+
+// Pressure reports the fullest connection queue.
+func (h *StreamHandler) Pressure() Pressure {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	p := Pressure{Dropped: h.Dropped()}
+	for c := range h.clients {
+		q := newPressure(len(c.ch), cap(c.ch), 0)
+		p.Queued += q.Queued
+		p.Capacity += q.Capacity
+		if q.Load > p.Load {
+			p.Load = q.Load
+		}
+	}
+	return p
+}
+
+func (h *StreamHandler) Write(p []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.clients) == 0 {
+		return len(p), nil
+	}
+	level := NoLevel
+	if event, err := DecodeEvent(p); err == nil {
+		if s, ok := event[LevelFieldName].(string); ok {
+			if l, err := ParseLevel(s); err == nil {
+				level = l
+			}
+		}
+	}
+	rendered := make(map[string][]byte)
+	for c := range h.clients {
+		if level < c.min && level != NoLevel {
+			continue
+		}
+		key := c.format
+		if c.color {
+			key += "+color"
+		}
+		b, ok := rendered[key]
+		if !ok {
+			b = renderStream(p, c.format, c.color)
+			rendered[key] = b
+		}
+		select {
+		case c.ch <- b:
+		default:
+			atomic.AddUint64(&h.dropped, 1)
+		}
+	}
+	return len(p), nil
+}
+
+func renderStream(p []byte, format string, color bool) []byte {
+	if format != "text" {
+		return bytes.TrimRight(append([]byte(nil), p...), "\r\n")
+	}
+	buf := &bytes.Buffer{}
+	if _, err := (ConsoleWriterEx{Out: buf, NoColor: !color}).Write(p); err != nil {
+		return bytes.TrimRight(append([]byte(nil), p...), "\r\n")
+	}
+	return bytes.TrimRight(buf.Bytes(), "\r\n")
+}
+
+func (h *StreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	q := r.URL.Query()
+	c := &streamClient{min: TraceLevel, format: q.Get("format"), color: q.Get("color") == "1"}
+	if s := q.Get("level"); s != "" {
+		l, err := ParseLevel(s)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.min = l
+	}
+	size := h.Buffer
+	if size <= 0 {
+		size = 256
+	}
+	c.ch = make(chan []byte, size)
+	h.mu.Lock()
+	if h.clients == nil {
+		h.clients = make(map[*streamClient]struct{})
+	}
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.clients, c)
+		h.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case b := <-c.ch:
+			w.Write([]byte("data: "))
+			w.Write(bytes.Replace(b, []byte("\n"), []byte("\ndata: "), -1))
+			w.Write([]byte("\n\n"))
+			flusher.Flush()
+		}
+	}
+}
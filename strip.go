@@ -0,0 +1,32 @@
+package consoleEx
+
+// appendStripped appends p to dst without ANSI escape sequences: CSI
+// sequences such as colors, and OSC sequences such as hyperlinks.
+func appendStripped(dst, p []byte) []byte {
+	for i := 0; i < len(p); i++ {
+		if p[i] != 0x1b || i+1 == len(p) {
+			dst = append(dst, p[i])
+			continue
+		}
+		switch p[i+1] {
+		case '[':
+			i += 2
+			for i < len(p) && (p[i] < 0x40 || p[i] > 0x7e) {
+				i++
+			}
+		case ']':
+			for i += 2; i < len(p); i++ {
+				if p[i] == 0x07 {
+					break
+				}
+				if p[i] == 0x1b && i+1 < len(p) && p[i+1] == '\\' {
+					i++
+					break
+				}
+			}
+		default:
+			i++
+		}
+	}
+	return dst
+}
@@ -0,0 +1,170 @@
+package consoleEx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+var summaryLevelOrder = []string{"trace", "debug", "info", "warn", "error", "fatal", "panic"}
+
+// Summary counts events per level in fixed time buckets and renders the
+// totals with a unicode sparkline of the volume over time. Set it as
+// ConsoleWriterEx.Summary and print it on shutdown or periodically.
+type Summary struct {
+	mu     sync.Mutex
+	bucket time.Duration
+	size   int
+	start  time.Time
+	counts map[string][]int
+	totals map[string]int
+}
+
+// NewSummary returns a Summary keeping the last size buckets of the given
+// duration, e.g. NewSummary(time.Minute, 60) for the last hour.
+func NewSummary(bucket time.Duration, size int) *Summary {
+	if bucket <= 0 {
+		bucket = time.Minute
+	}
+	if size <= 0 {
+		size = 60
+	}
+	return &Summary{
+		bucket: bucket,
+		size:   size,
+		counts: make(map[string][]int),
+		totals: make(map[string]int),
+	}
+}
+
+// Observe records one event of the given level at t.
+func (s *Summary) Observe(level string, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(t)
+	if i < 0 {
+		s.totals[level]++
+		return
+	}
+	c, ok := s.counts[level]
+	if !ok {
+		c = make([]int, s.size)
+		s.counts[level] = c
+	}
+	c[i]++
+	s.totals[level]++
+}
+
+// index returns the bucket for t, sliding the window forward if needed.
+func (s *Summary) index(t time.Time) int {
+	t = t.Truncate(s.bucket)
+	if s.start.IsZero() {
+		s.start = t
+	}
+	i := int(t.Sub(s.start) / s.bucket)
+	if i < s.size {
+		return i
+	}
+	shift := i - s.size + 1
+	for _, c := range s.counts {
+		if shift >= s.size {
+			for j := range c {
+				c[j] = 0
+			}
+			continue
+		}
+		copy(c, c[shift:])
+		for j := s.size - shift; j < s.size; j++ {
+			c[j] = 0
+		}
+	}
+	s.start = s.start.Add(time.Duration(shift) * s.bucket)
+	return s.size - 1
+}
+
+// String renders one line per level: label, total and sparkline.
+func (s *Summary) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	levels := make([]string, 0, len(s.totals))
+	for l := range s.totals {
+		levels = append(levels, l)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		return levelRank(levels[i]) < levelRank(levels[j])
+	})
+	buf := &bytes.Buffer{}
+	for _, l := range levels {
+		fmt.Fprintf(buf, "%-5s %8d %s\n", strings.ToUpper(l), s.totals[l], sparkline(s.counts[l]))
+	}
+	return buf.String()
+}
+
+func (s *Summary) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, s.String())
+	return int64(n), err
+}
+
+// Every writes the summary to w every d until the returned stop function
+// is called.
+func (s *Summary) Every(d time.Duration, w io.Writer) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.report(ctx, d, w, false)
+	return cancel
+}
+
+// Report writes the summary to w every d, and once more as the final
+// shutdown summary when ctx is done.
+func (s *Summary) Report(ctx context.Context, d time.Duration, w io.Writer) {
+	go s.report(ctx, d, w, true)
+}
+
+func (s *Summary) report(ctx context.Context, d time.Duration, w io.Writer, final bool) {
+	t := time.NewTicker(d)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			s.WriteTo(w)
+		case <-ctx.Done():
+			if final {
+				s.WriteTo(w)
+			}
+			return
+		}
+	}
+}
+
+func levelRank(level string) int {
+	for i, l := range summaryLevelOrder {
+		if l == level {
+			return i
+		}
+	}
+	return len(summaryLevelOrder)
+}
+
+func sparkline(counts []int) string {
+	max := 0
+	for _, c := range counts {
+		if c > max {
+			max = c
+		}
+	}
+	out := make([]rune, len(counts))
+	for i, c := range counts {
+		if c == 0 {
+			out[i] = ' '
+		} else {
+			out[i] = sparkTicks[c*(len(sparkTicks)-1)/max]
+		}
+	}
+	return string(out)
+}
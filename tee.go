@@ -0,0 +1,80 @@
+package consoleEx
+
+import (
+	"io"
+
+	. "github.com/rs/zerolog"
+)
+
+// TeeWriter writes every event to each of Writers independently. Unlike
+// zerolog's MultiLevelWriter, which only returns the first error, it tells
+// which writer failed and can fail over. A failing destination, such as a full disk or a closed pipe, is reported
+// to OnError and the event goes to Backup, if set, once however many
+// destinations failed. Write fails only if the event reached neither a
+// destination nor Backup. Levels passed by zerolog reach writers
+// implementing LevelWriter. No writer is closed.
+type TeeWriter struct {
+	Writers []io.Writer
+	Backup  io.Writer
+	OnError func(dest io.Writer, err error)
+}
+
+func NewTeeWriter(writers ...io.Writer) *TeeWriter {
+	return &TeeWriter{Writers: writers}
+}
+
+func (t *TeeWriter) Write(p []byte) (int, error) {
+	return t.write(p, func(w io.Writer) (int, error) { return w.Write(p) })
+}
+
+func (t *TeeWriter) WriteLevel(level Level, p []byte) (int, error) {
+	return t.write(p, func(w io.Writer) (int, error) {
+		if lw, ok := w.(LevelWriter); ok {
+			return lw.WriteLevel(level, p)
+		}
+		return w.Write(p)
+	})
+}
+
+func (t *TeeWriter) write(p []byte, write func(io.Writer) (int, error)) (int, error) {
+	var failed error
+	ok := false
+	for _, w := range t.Writers {
+		n, err := write(w)
+		if err == nil && n < len(p) {
+			err = io.ErrShortWrite
+		}
+		if err == nil {
+			ok = true
+			continue
+		}
+		if failed == nil {
+			failed = err
+		}
+		if t.OnError != nil {
+			t.OnError(w, err)
+		}
+	}
+	if failed == nil {
+		return len(p), nil
+	}
+	if t.Backup != nil {
+		if _, err := write(t.Backup); err == nil {
+			return len(p), nil
+		} else if t.OnError != nil {
+			t.OnError(t.Backup, err)
+		}
+	}
+	if ok {
+		return len(p), nil
+	}
+	return 0, failed
+}
+
+// Failover returns a Decorator copying events to w like Tee, but writing
+// to w and the rest of the chain independently, see TeeWriter.
+func Failover(w, backup io.Writer, onError func(dest io.Writer, err error)) Decorator {
+	return func(next io.Writer) io.Writer {
+		return &TeeWriter{Writers: []io.Writer{w, next}, Backup: backup, OnError: onError}
+	}
+}
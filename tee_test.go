@@ -0,0 +1,42 @@
+package consoleEx
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+type failWriter struct{ err error }
+
+func (f failWriter) Write(p []byte) (int, error) { return 0, f.err }
+
+func TestTeeWriterFailsOverBrokenConsole(t *testing.T) {
+	broken := errors.New("broken pipe")
+	console := ConsoleWriterEx{Out: failWriter{broken}, NoColor: true}
+	var file, backup bytes.Buffer
+	var failed []error
+	tee := &TeeWriter{
+		Writers: []io.Writer{console, &file},
+		Backup:  &backup,
+		OnError: func(dest io.Writer, err error) { failed = append(failed, err) },
+	}
+	event := []byte(`{"level":"info","message":"hello"}` + "\n")
+	if _, err := tee.Write(event); err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 || failed[0] != broken {
+		t.Fatalf("OnError got %v, want [%v]", failed, broken)
+	}
+	if file.String() != string(event) || backup.String() != string(event) {
+		t.Fatalf("file %q, backup %q, want the event in both", file.String(), backup.String())
+	}
+}
+
+func TestTeeWriterAllFailed(t *testing.T) {
+	broken := errors.New("disk full")
+	tee := NewTeeWriter(failWriter{broken}, failWriter{errors.New("closed")})
+	if _, err := tee.Write([]byte("{}\n")); err != broken {
+		t.Fatalf("got %v, want the first error %v", err, broken)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !tinygo && !consoleex_minimal
+
+package consoleEx
+
+import (
+	"io"
+
+	"github.com/mattn/go-colorable"
+	"github.com/mattn/go-isatty"
+)
+
+// stdout and stderr translate colors for the legacy Windows console.
+func stdout() io.Writer { return colorable.NewColorableStdout() }
+func stderr() io.Writer { return colorable.NewColorableStderr() }
+
+func isTerminal(fd uintptr) bool {
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
@@ -0,0 +1,16 @@
+//go:build tinygo || consoleex_minimal
+
+package consoleEx
+
+import (
+	"io"
+	"os"
+)
+
+// Builds for TinyGo, or tagged consoleex_minimal, do without go-colorable
+// and go-isatty: colors are written as plain escape codes and no output is
+// taken for a terminal.
+func stdout() io.Writer { return os.Stdout }
+func stderr() io.Writer { return os.Stderr }
+
+func isTerminal(fd uintptr) bool { return false }
@@ -0,0 +1,210 @@
+package consoleEx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Color is a foreground color: one of the 16 ANSI colors, an index into
+// the 256-color palette or a 24-bit RGB value. The zero Color leaves the
+// terminal's default.
+type Color struct {
+	kind uint8
+	v    uint32
+}
+
+const (
+	colorKindANSI = iota + 1
+	colorKind256
+	colorKindRGB
+)
+
+// ANSIColor returns one of the basic colors by its SGR code, 30-37 or
+// 90-97.
+func ANSIColor(code int) Color { return Color{colorKindANSI, uint32(code)} }
+
+// Color256 returns a color of the 256-color palette.
+func Color256(n uint8) Color { return Color{colorKind256, uint32(n)} }
+
+// RGB returns a 24-bit truecolor color.
+func RGB(r, g, b uint8) Color {
+	return Color{colorKindRGB, uint32(r)<<16 | uint32(g)<<8 | uint32(b)}
+}
+
+func (c Color) sgr() string {
+	switch c.kind {
+	case colorKindANSI:
+		return strconv.Itoa(int(c.v))
+	case colorKind256:
+		return "38;5;" + strconv.Itoa(int(c.v))
+	case colorKindRGB:
+		return fmt.Sprintf("38;2;%d;%d;%d", c.v>>16, c.v>>8&0xff, c.v&0xff)
+	}
+	return ""
+}
+
+func (c Color) tag() string {
+	switch c.kind {
+	case colorKindANSI:
+		if name, ok := colorTagNames[int(c.v)]; ok {
+			return name
+		}
+		return strconv.Itoa(int(c.v))
+	case colorKind256:
+		return "c" + strconv.Itoa(int(c.v))
+	case colorKindRGB:
+		return fmt.Sprintf("#%06x", c.v)
+	}
+	return ""
+}
+
+// Style is a color with text attributes.
+type Style struct {
+	Color     Color
+	Bold      bool
+	Underline bool
+}
+
+func (s Style) render(v interface{}, mode colorMode, p ColorProfile) string {
+	if mode == colorOff || s == (Style{}) {
+		return fmt.Sprintf("%v", v)
+	}
+	var codes, tags []string
+	if s.Bold {
+		codes, tags = append(codes, "1"), append(tags, "bold")
+	}
+	if s.Underline {
+		codes, tags = append(codes, "4"), append(tags, "underline")
+	}
+	if c := s.Color.downgrade(p); c.kind != 0 {
+		codes, tags = append(codes, c.sgr()), append(tags, c.tag())
+	}
+	if mode == colorTags {
+		name := strings.Join(tags, "+")
+		return fmt.Sprintf("<%s>%v</%s>", name, v, name)
+	}
+	return fmt.Sprintf("\x1b[%sm%v\x1b[0m", strings.Join(codes, ";"), v)
+}
+
+// Theme sets the styles ConsoleWriterEx renders the event line with. Parts
+// with a zero Style, and levels missing from Levels, are left unstyled.
+type Theme struct {
+	Timestamp  Style
+	Caller     Style
+	Func       Style
+	Message    Style
+	FieldName  Style
+	FieldValue Style
+	// Levels maps level names such as "warn" to their style.
+	Levels map[string]Style
+}
+
+var themeRegistry = struct {
+	sync.RWMutex
+	m map[string]*Theme
+}{m: map[string]*Theme{
+	"dark": {
+		Timestamp: Style{Color: Color256(244)},
+		Func:      Style{Color: Color256(75)},
+		FieldName: Style{Color: Color256(80)},
+		Levels: map[string]Style{
+			"trace": {Color: Color256(245)},
+			"debug": {Color: Color256(141)},
+			"info":  {Color: Color256(114)},
+			"warn":  {Color: Color256(221), Bold: true},
+			"error": {Color: Color256(203), Bold: true},
+			"fatal": {Color: RGB(255, 64, 64), Bold: true, Underline: true},
+			"panic": {Color: RGB(255, 64, 64), Bold: true, Underline: true},
+			"audit": {Color: Color256(177), Bold: true},
+		},
+	},
+	"light": {
+		Timestamp: Style{Color: Color256(242)},
+		Func:      Style{Color: Color256(25)},
+		FieldName: Style{Color: Color256(30)},
+		Levels: map[string]Style{
+			"trace": {Color: Color256(240)},
+			"debug": {Color: Color256(91)},
+			"info":  {Color: Color256(28)},
+			"warn":  {Color: Color256(130), Bold: true},
+			"error": {Color: Color256(160), Bold: true},
+			"fatal": {Color: RGB(176, 0, 0), Bold: true, Underline: true},
+			"panic": {Color: RGB(176, 0, 0), Bold: true, Underline: true},
+			"audit": {Color: Color256(90), Bold: true},
+		},
+	},
+	"mono-bold": {
+		FieldName: Style{Bold: true},
+		Levels: map[string]Style{
+			"warn":  {Bold: true},
+			"error": {Bold: true},
+			"fatal": {Bold: true, Underline: true},
+			"panic": {Bold: true, Underline: true},
+			"audit": {Bold: true},
+		},
+	},
+}}
+
+// RegisterTheme makes t available to LookupTheme under name, replacing any
+// theme registered for it before. The built-in themes are "dark", "light"
+// and "mono-bold".
+func RegisterTheme(name string, t *Theme) {
+	themeRegistry.Lock()
+	defer themeRegistry.Unlock()
+	themeRegistry.m[name] = t
+}
+
+// LookupTheme returns the theme registered under name.
+func LookupTheme(name string) (*Theme, bool) {
+	themeRegistry.RLock()
+	defer themeRegistry.RUnlock()
+	t, ok := themeRegistry.m[name]
+	return t, ok
+}
+
+type themePart int
+
+const (
+	partTimestamp themePart = iota
+	partCaller
+	partFunc
+	partMessage
+	partFieldName
+	partFieldValue
+)
+
+func (t *Theme) style(part themePart) Style {
+	switch part {
+	case partTimestamp:
+		return t.Timestamp
+	case partCaller:
+		return t.Caller
+	case partFunc:
+		return t.Func
+	case partMessage:
+		return t.Message
+	case partFieldName:
+		return t.FieldName
+	}
+	return t.FieldValue
+}
+
+// paint styles a part of the event line with the Theme if one is set and
+// with the built-in color def otherwise.
+func (w ConsoleWriterEx) paint(v interface{}, part themePart, def int) string {
+	if w.Theme != nil {
+		return w.Theme.style(part).render(v, w.colors(), w.Profile)
+	}
+	return colorize(v, def, w.colors())
+}
+
+// paintLevel styles the level label; audit events use the "audit" entry
+// of Theme.Levels.
+func (w ConsoleWriterEx) paintLevel(v interface{}, level string, def int) string {
+	if w.Theme != nil {
+		return w.Theme.Levels[level].render(v, w.colors(), w.Profile)
+	}
+	return colorize(v, def, w.colors())
+}
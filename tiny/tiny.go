@@ -0,0 +1,106 @@
+// Package tiny is a minimal console writer for zerolog events, for TinyGo
+// and WASM builds where consoleEx itself does not fit. It depends on the
+// standard library only and renders the default consoleEx line:
+//
+//	2024-06-01T10:15:00Z |INFO| main.go:12 |started port=8080
+package tiny
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Field names, matching zerolog's defaults.
+var (
+	TimestampFieldName = "time"
+	LevelFieldName     = "level"
+	MessageFieldName   = "message"
+	CallerFieldName    = "caller"
+)
+
+var levelColors = map[string]string{
+	"trace": "\x1b[34m",
+	"debug": "\x1b[35m",
+	"info":  "\x1b[32m",
+	"warn":  "\x1b[33m",
+	"error": "\x1b[31m",
+	"fatal": "\x1b[31m",
+	"panic": "\x1b[31m",
+}
+
+// Writer renders each JSON event written to it as one line on Out, with
+// the level colored unless NoColor is set. Lines that are not JSON
+// objects are passed through.
+type Writer struct {
+	Out     io.Writer
+	NoColor bool
+}
+
+func (w Writer) Write(p []byte) (int, error) {
+	d := json.NewDecoder(bytes.NewReader(p))
+	d.UseNumber()
+	var event map[string]interface{}
+	if err := d.Decode(&event); err != nil {
+		return w.Out.Write(p)
+	}
+	var buf bytes.Buffer
+	level, _ := event[LevelFieldName].(string)
+	label := "????"
+	if level != "" {
+		l := []rune(strings.ToUpper(level))
+		if len(l) > 4 {
+			l = l[:4]
+		}
+		label = string(l)
+	}
+	if c, ok := levelColors[level]; ok && !w.NoColor {
+		label = c + label + "\x1b[0m"
+	}
+	buf.WriteString(str(event[TimestampFieldName]))
+	buf.WriteString(" |" + label + "| ")
+	if caller, ok := event[CallerFieldName]; ok {
+		buf.WriteString(str(caller) + " |")
+	}
+	buf.WriteString(str(event[MessageFieldName]))
+	fields := make([]string, 0, len(event))
+	for k := range event {
+		switch k {
+		case TimestampFieldName, LevelFieldName, MessageFieldName, CallerFieldName:
+		default:
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+	for _, k := range fields {
+		v := str(event[k])
+		if s, ok := event[k].(string); ok && strings.ContainsAny(s, " \"\\\n") {
+			v = strconv.Quote(s)
+		}
+		buf.WriteString(" " + k + "=" + v)
+	}
+	buf.WriteByte('\n')
+	if _, err := w.Out.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func str(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "?"
+	}
+	return string(b)
+}
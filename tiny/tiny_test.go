@@ -0,0 +1,21 @@
+package tiny
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLabelTruncatesRunes(t *testing.T) {
+	for level, want := range map[string]string{
+		"info":    "|INFO|",
+		"warning": "|WARN|",
+		"ошибка":  "|ОШИБ|",
+		"":        "|????|",
+	} {
+		var buf bytes.Buffer
+		Writer{Out: &buf, NoColor: true}.Write([]byte(`{"level":"` + level + `","message":"m"}`))
+		if !bytes.Contains(buf.Bytes(), []byte(want)) {
+			t.Errorf("level %q rendered %q, want %s", level, buf.String(), want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package consoleEx
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// TLSConfig is the TLS setup shared by the network sinks.
+type TLSConfig struct {
+	// CertFile and KeyFile hold the client certificate for mutual TLS.
+	CertFile string
+	KeyFile  string
+	// CAFile replaces the system roots with the PEM certificates it holds.
+	CAFile string
+	// ServerName overrides the name sent via SNI and verified.
+	ServerName string
+	// PinSHA256 restricts the server to leaf certificates whose public key
+	// (SubjectPublicKeyInfo) has one of these SHA-256 hashes, hex or base64.
+	PinSHA256          []string
+	InsecureSkipVerify bool
+}
+
+// Build returns the equivalent *tls.Config.
+func (c TLSConfig) Build() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("consoleEx: no certificates in %s", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if len(c.PinSHA256) > 0 {
+		pins := make(map[string]bool, len(c.PinSHA256))
+		for _, p := range c.PinSHA256 {
+			b, err := hex.DecodeString(p)
+			if err != nil {
+				if b, err = base64.StdEncoding.DecodeString(p); err != nil {
+					return nil, fmt.Errorf("consoleEx: invalid pin %q", p)
+				}
+			}
+			pins[string(b)] = true
+		}
+		cfg.VerifyPeerCertificate = func(raw [][]byte, _ [][]*x509.Certificate) error {
+			if len(raw) == 0 {
+				return errors.New("consoleEx: no server certificate")
+			}
+			leaf, err := x509.ParseCertificate(raw[0])
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256(leaf.RawSubjectPublicKeyInfo)
+			if !pins[string(sum[:])] {
+				return errors.New("consoleEx: server certificate does not match pin")
+			}
+			return nil
+		}
+	}
+	return cfg, nil
+}
+
+var tlsParams = []string{"cert", "key", "ca", "servername", "pin", "insecure"}
+
+// sinkTLS reads the TLS query parameters of a sink URI; it returns nil if
+// there are none and force is not set.
+func sinkTLS(u *url.URL, force bool) (*tls.Config, error) {
+	q := u.Query()
+	set := force
+	for _, p := range tlsParams {
+		if q.Get(p) != "" {
+			set = true
+		}
+	}
+	if !set {
+		return nil, nil
+	}
+	c := TLSConfig{
+		CertFile:           q.Get("cert"),
+		KeyFile:            q.Get("key"),
+		CAFile:             q.Get("ca"),
+		ServerName:         q.Get("servername"),
+		InsecureSkipVerify: q.Get("insecure") == "1" || strings.EqualFold(q.Get("insecure"), "true"),
+	}
+	for _, p := range q["pin"] {
+		c.PinSHA256 = append(c.PinSHA256, strings.Split(p, ",")...)
+	}
+	return c.Build()
+}
@@ -0,0 +1,92 @@
+package consoleEx
+
+import (
+	"flag"
+
+	. "github.com/rs/zerolog"
+)
+
+// Verbosity is the amount of output a command line tool asks for, from
+// Quiet (-q) through Normal to VeryVerbose (-vv).
+type Verbosity int
+
+const (
+	Quiet Verbosity = iota - 1
+	Normal
+	Verbose
+	VeryVerbose
+)
+
+// Level returns the minimum level shown: warn, info, debug or trace.
+func (v Verbosity) Level() Level {
+	switch {
+	case v <= Quiet:
+		return WarnLevel
+	case v == Normal:
+		return InfoLevel
+	case v == Verbose:
+		return DebugLevel
+	}
+	return TraceLevel
+}
+
+// Layout returns the matching preset: compact when quiet, expanded at
+// -vv, the default otherwise.
+func (v Verbosity) Layout() Layout {
+	switch {
+	case v <= Quiet:
+		return LayoutCompact
+	case v >= VeryVerbose:
+		return LayoutExpanded
+	}
+	return LayoutDefault
+}
+
+// Apply sets the minimum level and layout of w.
+func (v Verbosity) Apply(w *ConsoleWriterEx) {
+	l := v.Level()
+	w.MinLevel = &l
+	w.Layout = v.Layout()
+}
+
+// VerbosityFlags holds the -q, -v and -vv flags.
+type VerbosityFlags struct {
+	quiet, verbose, veryVerbose bool
+	count                       int
+}
+
+// Verbosity combines the parsed flags; -q wins over -v.
+func (f *VerbosityFlags) Verbosity() Verbosity {
+	switch {
+	case f.quiet:
+		return Quiet
+	case f.veryVerbose || f.count >= 2:
+		return VeryVerbose
+	case f.verbose || f.count == 1:
+		return Verbose
+	}
+	return Normal
+}
+
+// Apply sets w from the parsed flags, see Verbosity.Apply.
+func (f *VerbosityFlags) Apply(w *ConsoleWriterEx) {
+	f.Verbosity().Apply(w)
+}
+
+// AddVerbosityFlags registers -q, -v and -vv on fs, or flag.CommandLine if
+// fs is nil. Read the result after parsing:
+//
+//	vf := consoleEx.AddVerbosityFlags(nil)
+//	flag.Parse()
+//	w := consoleEx.ConsoleWriterEx{Out: os.Stderr}
+//	vf.Apply(&w)
+func AddVerbosityFlags(fs *flag.FlagSet) *VerbosityFlags {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	f := &VerbosityFlags{}
+	fs.BoolVar(&f.quiet, "q", false, "only show warnings and errors")
+	fs.BoolVar(&f.verbose, "v", false, "show debug output")
+	fs.BoolVar(&f.veryVerbose, "vv", false, "show trace output with every field on its own line")
+	return f
+}
@@ -0,0 +1,18 @@
+//go:build pflag
+
+package consoleEx
+
+import "github.com/spf13/pflag"
+
+// AddVerbosityPFlags registers -q/--quiet and a repeatable -v/--verbose on
+// fs, or pflag.CommandLine if fs is nil, so -v and -vv work as with
+// AddVerbosityFlags.
+func AddVerbosityPFlags(fs *pflag.FlagSet) *VerbosityFlags {
+	if fs == nil {
+		fs = pflag.CommandLine
+	}
+	f := &VerbosityFlags{}
+	fs.BoolVarP(&f.quiet, "quiet", "q", false, "only show warnings and errors")
+	fs.CountVarP(&f.count, "verbose", "v", "show debug output, twice for trace output")
+	return f
+}
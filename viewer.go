@@ -0,0 +1,112 @@
+package consoleEx
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+
+	. "github.com/rs/zerolog"
+)
+
+//go:embed web
+var webFS embed.FS
+
+// NewViewer returns a handler serving the embedded web log viewer: the UI
+// at /, recent events from ring at /recent and the live stream at /events.
+// Mount it under a prefix with http.StripPrefix. ring may be nil. Events
+// are colored as ConsoleWriterEx colors them by default.
+func NewViewer(stream *StreamHandler, ring *RingBuffer) http.Handler {
+	return NewThemedViewer(stream, ring, nil)
+}
+
+// NewThemedViewer is NewViewer coloring events with theme, as
+// ConsoleWriterEx does with its Theme set.
+func NewThemedViewer(stream *StreamHandler, ring *RingBuffer, theme *Theme) http.Handler {
+	if theme == nil {
+		theme = builtinTheme
+	}
+	mux := http.NewServeMux()
+	static, _ := fs.Sub(webFS, "web")
+	mux.Handle("/", http.FileServer(http.FS(static)))
+	mux.Handle("/events", stream)
+	if ring != nil {
+		mux.Handle("/recent", ring)
+	} else {
+		mux.HandleFunc("/recent", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("[]\n"))
+		})
+	}
+	mux.HandleFunc("/config", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"level":   LevelFieldName,
+			"time":    TimestampFieldName,
+			"message": MessageFieldName,
+			"caller":  CallerFieldName,
+			"theme":   themeCSS(theme),
+		})
+	})
+	return mux
+}
+
+// builtinTheme has the colors ConsoleWriterEx uses without a Theme.
+var builtinTheme = &Theme{
+	Timestamp: Style{Color: ANSIColor(cDarkGray)},
+	Func:      Style{Color: ANSIColor(cBlue)},
+	FieldName: Style{Color: ANSIColor(cCyan)},
+	Levels: map[string]Style{
+		"trace": {Color: ANSIColor(cBlue)},
+		"debug": {Color: ANSIColor(cMagenta)},
+		"info":  {Color: ANSIColor(cGreen)},
+		"warn":  {Color: ANSIColor(cYellow)},
+		"error": {Color: ANSIColor(cRed)},
+		"fatal": {Color: ANSIColor(cRed)},
+		"panic": {Color: ANSIColor(cRed)},
+		"audit": {Color: ANSIColor(cMagenta)},
+	},
+}
+
+// themeCSS returns the declarations the viewer styles each part with.
+func themeCSS(t *Theme) map[string]interface{} {
+	levels := make(map[string]string, len(t.Levels))
+	for name, s := range t.Levels {
+		levels[name] = s.css()
+	}
+	return map[string]interface{}{
+		"time":    t.Timestamp.css(),
+		"caller":  t.Caller.css(),
+		"func":    t.Func.css(),
+		"message": t.Message.css(),
+		"key":     t.FieldName.css(),
+		"value":   t.FieldValue.css(),
+		"levels":  levels,
+	}
+}
+
+func (s Style) css() string {
+	var decl []string
+	if c := s.Color; c.kind == colorKindANSI {
+		switch {
+		case c.v >= 30 && c.v <= 37:
+			c = Color256(uint8(c.v - 30))
+		case c.v >= 90 && c.v <= 97:
+			c = Color256(uint8(c.v - 90 + 8))
+		}
+		s.Color = c
+	}
+	if s.Color.kind == colorKind256 || s.Color.kind == colorKindRGB {
+		r, g, b := s.Color.rgb()
+		decl = append(decl, fmt.Sprintf("color:#%02x%02x%02x", r, g, b))
+	}
+	if s.Bold {
+		decl = append(decl, "font-weight:bold")
+	}
+	if s.Underline {
+		decl = append(decl, "text-decoration:underline")
+	}
+	return strings.Join(decl, ";")
+}
@@ -0,0 +1,180 @@
+package consoleEx
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	. "github.com/rs/zerolog"
+)
+
+// VModuleEnv names the environment variable NewVModule reads its initial
+// spec from.
+var VModuleEnv = "CONSOLEEX_VMODULE"
+
+// VModule filters events by a per component minimum level that can be
+// changed at runtime, in the spirit of glog's -vmodule. A spec such as
+// "auth=debug,db=warn,*=info" maps values of Field, which may be
+// path.Match patterns, to levels; "*" sets the level of everything else.
+// Events without a level are always kept. The logger itself must be at the
+// most verbose level wanted, since VModule can only drop events.
+type VModule struct {
+	Next  io.Writer
+	Field string
+
+	rules atomic.Value
+}
+
+type vmoduleRules struct {
+	spec     string
+	exact    map[string]Level
+	patterns []vmodulePattern
+	def      Level
+}
+
+type vmodulePattern struct {
+	pattern string
+	level   Level
+}
+
+// NewVModule filters on the "component" field with the spec in
+// $CONSOLEEX_VMODULE, passing everything if it is unset or invalid.
+func NewVModule(next io.Writer) *VModule {
+	v := &VModule{Next: next, Field: "component"}
+	if err := v.Set(os.Getenv(VModuleEnv)); err != nil {
+		v.Set("")
+	}
+	return v
+}
+
+// Set replaces all rules with spec.
+func (v *VModule) Set(spec string) error {
+	r, err := parseVModule(spec)
+	if err != nil {
+		return err
+	}
+	v.rules.Store(r)
+	return nil
+}
+
+// String returns the spec in effect.
+func (v *VModule) String() string {
+	return v.load().spec
+}
+
+// Level returns the minimum level of module.
+func (v *VModule) Level(module string) Level {
+	return v.load().level(module)
+}
+
+func (v *VModule) load() *vmoduleRules {
+	if r, ok := v.rules.Load().(*vmoduleRules); ok {
+		return r
+	}
+	return &vmoduleRules{def: TraceLevel}
+}
+
+func parseVModule(spec string) (*vmoduleRules, error) {
+	r := &vmoduleRules{exact: make(map[string]Level), def: TraceLevel}
+	var parts []string
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		eq := strings.IndexByte(item, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("consoleEx: invalid vmodule rule %q", item)
+		}
+		name := strings.TrimSpace(item[:eq])
+		l, err := ParseLevel(strings.TrimSpace(item[eq+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("consoleEx: invalid vmodule rule %q: %v", item, err)
+		}
+		if _, err := path.Match(name, ""); err != nil {
+			return nil, fmt.Errorf("consoleEx: invalid vmodule pattern %q", name)
+		}
+		switch {
+		case name == "*":
+			r.def = l
+		case strings.ContainsAny(name, "*?["):
+			r.patterns = append(r.patterns, vmodulePattern{name, l})
+		default:
+			r.exact[name] = l
+		}
+		parts = append(parts, name+"="+l.String())
+	}
+	sort.Strings(parts)
+	r.spec = strings.Join(parts, ",")
+	return r, nil
+}
+
+func (r *vmoduleRules) level(module string) Level {
+	if l, ok := r.exact[module]; ok {
+		return l
+	}
+	for _, p := range r.patterns {
+		if ok, _ := path.Match(p.pattern, module); ok {
+			return p.level
+		}
+	}
+	return r.def
+}
+
+func (v *VModule) Write(p []byte) (int, error) {
+	r := v.load()
+	if len(r.exact) == 0 && len(r.patterns) == 0 && r.def <= TraceLevel {
+		return v.Next.Write(p)
+	}
+	event, err := DecodeEvent(p)
+	if err != nil {
+		return v.Next.Write(p)
+	}
+	s, ok := event[LevelFieldName].(string)
+	if !ok {
+		return v.Next.Write(p)
+	}
+	l, err := ParseLevel(s)
+	if err != nil || l == NoLevel {
+		return v.Next.Write(p)
+	}
+	module := ""
+	if m, ok := event[v.Field]; ok {
+		module = valueString(m)
+	}
+	if l < r.level(module) {
+		return len(p), nil
+	}
+	return v.Next.Write(p)
+}
+
+// ServeHTTP responds with the spec in effect and replaces it with the
+// request body on PUT or POST, e.g.
+//
+//	curl -X PUT -d 'auth=debug,*=info' localhost:6060/debug/vmodule
+func (v *VModule) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPut, http.MethodPost:
+		body, err := io.ReadAll(io.LimitReader(req.Body, 64*1024))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := v.Set(string(body)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, v.String())
+}
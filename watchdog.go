@@ -0,0 +1,180 @@
+package consoleEx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	. "github.com/rs/zerolog"
+)
+
+// ErrStalled is returned by a Watchdog without Fallback while its sink is
+// unhealthy.
+var ErrStalled = errors.New("consoleEx: sink stalled")
+
+// Watchdog gives each write to Primary Timeout to complete. A write that
+// takes longer, as on a hung NFS mount or a dead TCP peer, marks Primary
+// unhealthy: that event and the following ones go to Fallback, and every
+// ProbeInterval the Watchdog checks whether the stalled write has returned
+// and, if Primary is a SelfChecker, whether it passes its check, before
+// routing back to it. The stalled event reaches Primary too if its write
+// eventually completes. Transitions are reported with SetDiagnostics.
+//
+// The zero value, with Primary set, is ready to use; a Timeout or
+// ProbeInterval that is not positive takes the default of NewWatchdog.
+type Watchdog struct {
+	Name          string
+	Primary       io.Writer
+	Fallback      io.Writer
+	Timeout       time.Duration
+	ProbeInterval time.Duration
+
+	mu        sync.Mutex
+	unhealthy bool
+	since     time.Time
+	stalled   chan error
+	done      chan struct{}
+	once      sync.Once
+}
+
+// NewWatchdog allows writes five seconds and probes every ten seconds.
+// fallback may be nil to fail writes with ErrStalled instead.
+func NewWatchdog(name string, primary, fallback io.Writer) *Watchdog {
+	return &Watchdog{
+		Name:          name,
+		Primary:       primary,
+		Fallback:      fallback,
+		Timeout:       defaultWatchdogTimeout,
+		ProbeInterval: defaultProbeInterval,
+	}
+}
+
+const (
+	defaultWatchdogTimeout = 5 * time.Second
+	defaultProbeInterval   = 10 * time.Second
+)
+
+func (w *Watchdog) timeout() time.Duration {
+	if w.Timeout <= 0 {
+		return defaultWatchdogTimeout
+	}
+	return w.Timeout
+}
+
+// doneChan returns the channel closed by Close; w.mu must be held.
+func (w *Watchdog) doneChan() chan struct{} {
+	if w.done == nil {
+		w.done = make(chan struct{})
+	}
+	return w.done
+}
+
+func (w *Watchdog) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.unhealthy {
+		return w.fallback(p)
+	}
+	res := make(chan error, 1)
+	q := append([]byte(nil), p...)
+	go func() {
+		_, err := w.Primary.Write(q)
+		res <- err
+	}()
+	t := time.NewTimer(w.timeout())
+	defer t.Stop()
+	select {
+	case err := <-res:
+		if err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	case <-t.C:
+	}
+	w.unhealthy, w.since, w.stalled = true, time.Now(), res
+	if e := diag(WarnLevel); e != nil {
+		e.Str("sink", w.Name).Dur("timeout", w.timeout()).Bool("fallback", w.Fallback != nil).Msg("sink stalled")
+	}
+	interval := w.ProbeInterval
+	if interval <= 0 {
+		interval = defaultProbeInterval
+	}
+	go w.probe(interval, w.doneChan())
+	return w.fallback(p)
+}
+
+func (w *Watchdog) fallback(p []byte) (int, error) {
+	if w.Fallback == nil {
+		return 0, ErrStalled
+	}
+	return w.Fallback.Write(p)
+}
+
+// probe waits for Primary to recover and marks it healthy again.
+func (w *Watchdog) probe(interval time.Duration, done chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-done:
+			return
+		}
+		w.mu.Lock()
+		stalled := w.stalled
+		w.mu.Unlock()
+		select {
+		case <-stalled:
+		default:
+			continue
+		}
+		if sc, ok := w.Primary.(SelfChecker); ok {
+			ctx, cancel := context.WithTimeout(context.Background(), w.timeout())
+			err := sc.SelfCheck(ctx)
+			cancel()
+			if err != nil {
+				if e := diag(DebugLevel); e != nil {
+					e.Str("sink", w.Name).Err(err).Msg("sink probe failed")
+				}
+				continue
+			}
+		}
+		w.mu.Lock()
+		w.unhealthy = false
+		down := time.Since(w.since)
+		w.mu.Unlock()
+		if e := diag(InfoLevel); e != nil {
+			e.Str("sink", w.Name).Dur("down", down).Msg("sink recovered")
+		}
+		return
+	}
+}
+
+// Healthy reports whether writes currently go to Primary.
+func (w *Watchdog) Healthy() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !w.unhealthy
+}
+
+func (w *Watchdog) SelfCheck(ctx context.Context) error {
+	if !w.Healthy() {
+		return ErrStalled
+	}
+	if sc, ok := w.Primary.(SelfChecker); ok {
+		return sc.SelfCheck(ctx)
+	}
+	return nil
+}
+
+// Close stops probing. Primary and Fallback are not closed.
+func (w *Watchdog) Close() error {
+	w.once.Do(func() {
+		w.mu.Lock()
+		close(w.doneChan())
+		w.mu.Unlock()
+	})
+	return nil
+}
@@ -0,0 +1,42 @@
+package consoleEx
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+type stallWriter struct{ release chan struct{} }
+
+func (w stallWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+func TestWatchdogZeroValue(t *testing.T) {
+	var primary bytes.Buffer
+	w := &Watchdog{Primary: &primary}
+	if _, err := w.Write([]byte("one\n")); err != nil {
+		t.Fatal(err)
+	}
+	if primary.String() != "one\n" || !w.Healthy() {
+		t.Fatalf("got %q, healthy %v; want the write on Primary", primary.String(), w.Healthy())
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchdogStallWithoutProbeInterval(t *testing.T) {
+	stall := stallWriter{make(chan struct{})}
+	var fallback bytes.Buffer
+	w := &Watchdog{Primary: stall, Fallback: &fallback, Timeout: 10 * time.Millisecond}
+	defer w.Close()
+	if _, err := w.Write([]byte("stuck\n")); err != nil {
+		t.Fatal(err)
+	}
+	if w.Healthy() || fallback.String() != "stuck\n" {
+		t.Fatalf("healthy %v, fallback %q; want the event on Fallback", w.Healthy(), fallback.String())
+	}
+	close(stall.release)
+}